@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"github.com/rady-io/http-service/impl"
+	"github.com/rady-io/http-service/log"
+	"go/ast"
+	"go/token"
+	"golang.org/x/tools/go/analysis"
+	"strings"
+)
+
+// Analyzer reports annotation errors of services, the same as impler lint;
+// a service is an interface type in a declaration marked by @HttpService
+var Analyzer = &analysis.Analyzer{
+	Name: "impler",
+	Doc:  "check annotations of http services generated by impler",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	log.SetQuiet()
+	for _, file := range pass.Files {
+		cmap := ast.NewCommentMap(pass.Fset, file, file.Comments)
+		for _, name := range services(file) {
+			service := impl.NewService(name, pass.TypesInfo).InitComments(cmap)
+			for _, diagnostic := range impl.Lint(service, pass.Pkg.Path()) {
+				pass.Report(analysis.Diagnostic{
+					Pos:            diagnostic.Pos,
+					Message:        diagnostic.Message,
+					SuggestedFixes: suggestedFixes(diagnostic.Fixes),
+				})
+			}
+		}
+	}
+	return nil, nil
+}
+
+// names of interface types in declarations marked by @HttpService
+func services(file *ast.File) []string {
+	names := make([]string, 0)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE || genDecl.Doc == nil || !strings.Contains(genDecl.Doc.Text(), impl.HttpServiceAnn) {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec := spec.(*ast.TypeSpec); isInterface(typeSpec) {
+				names = append(names, typeSpec.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+func isInterface(spec *ast.TypeSpec) bool {
+	_, ok := spec.Type.(*ast.InterfaceType)
+	return ok
+}
+
+func suggestedFixes(fixes []*impl.Fix) []analysis.SuggestedFix {
+	suggested := make([]analysis.SuggestedFix, 0, len(fixes))
+	for _, fix := range fixes {
+		suggested = append(suggested, analysis.SuggestedFix{
+			Message:   fix.Message,
+			TextEdits: []analysis.TextEdit{{Pos: fix.Pos, End: fix.End, NewText: []byte(fix.NewText)}},
+		})
+	}
+	return suggested
+}
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"testing"
+)
+
+const source = `package box
+
+import "net/http"
+
+/*
+@HttpService
+*/
+type (
+	Service interface {
+		/*
+		@Gett /items/{id}
+		*/
+		GetItem(id int) (*http.Response, error)
+
+		/*
+		@Post /items
+		*/
+		AddItem(name string) (*http.Response, error)
+	}
+)
+`
+
+func TestAnalyzer(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "box.go", source, parser.ParseComments)
+	assert.Nil(t, err)
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object), Uses: make(map[*ast.Ident]types.Object)}
+	pkg, err := (&types.Config{Importer: importer.Default()}).Check("box", fset, []*ast.File{file}, info)
+	assert.Nil(t, err)
+
+	diagnostics := make([]analysis.Diagnostic, 0)
+	_, err = Analyzer.Run(&analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fset,
+		Files:     []*ast.File{file},
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report: func(diagnostic analysis.Diagnostic) {
+			diagnostics = append(diagnostics, diagnostic)
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, diagnostics, 2)
+
+	assert.Equal(t, "box.go:11:3", fset.Position(diagnostics[0].Pos).String())
+	assert.Equal(t, "unknown annotation @Gett", diagnostics[0].Message)
+	assert.Equal(t, "@Get", string(diagnostics[0].SuggestedFixes[0].TextEdits[0].NewText))
+
+	assert.Equal(t, "param name is not used by any annotation and is sent in the json body of AddItem", diagnostics[1].Message)
+	edit := diagnostics[1].SuggestedFixes[0].TextEdits[0]
+	assert.Equal(t, "box.go:16:3", fset.Position(edit.Pos).String())
+	assert.Equal(t, "@Body json\n\t\t", string(edit.NewText))
+}
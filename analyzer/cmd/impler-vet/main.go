@@ -0,0 +1,13 @@
+// impler-vet runs the impler analyzer by go vet:
+//
+//	go vet -vettool=$(which impler-vet) ./...
+package main
+
+import (
+	"github.com/rady-io/http-service/analyzer"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"math/rand"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Endpoint is a base url with its health; its state is guarded by BaseURL
+	Endpoint struct {
+		url          *url.URL
+		failures     int // consecutive
+		ejectedUntil time.Time
+	}
+
+	// Balancer picks one of endpoints, which is never empty
+	Balancer interface {
+		Pick(endpoints []*Endpoint) *Endpoint
+	}
+
+	roundRobin struct {
+		next uint32
+	}
+
+	random struct{}
+
+	leastFailures struct {
+		roundRobin
+	}
+)
+
+func (endpoint *Endpoint) URL() *url.URL {
+	copied := *endpoint.url
+	return &copied
+}
+
+// Failures of endpoint in a row; it is only safe to read in Balancer.Pick, which holds the lock of BaseURL,
+// elsewhere use BaseURL.Failures
+func (endpoint *Endpoint) Failures() int {
+	return endpoint.failures
+}
+
+func RoundRobin() Balancer {
+	return new(roundRobin)
+}
+
+func Random() Balancer {
+	return random{}
+}
+
+// LeastFailures picks endpoints with the fewest consecutive failures in turn
+func LeastFailures() Balancer {
+	return new(leastFailures)
+}
+
+func (balancer *roundRobin) Pick(endpoints []*Endpoint) *Endpoint {
+	return endpoints[int(atomic.AddUint32(&balancer.next, 1)-1)%len(endpoints)]
+}
+
+func (random) Pick(endpoints []*Endpoint) *Endpoint {
+	return endpoints[rand.Intn(len(endpoints))]
+}
+
+func (balancer *leastFailures) Pick(endpoints []*Endpoint) *Endpoint {
+	least := make([]*Endpoint, 0)
+	for _, endpoint := range endpoints {
+		if len(least) != 0 && endpoint.failures > least[0].failures {
+			continue
+		}
+		if len(least) != 0 && endpoint.failures < least[0].failures {
+			least = least[:0]
+		}
+		least = append(least, endpoint)
+	}
+	return balancer.roundRobin.Pick(least)
+}
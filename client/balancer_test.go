@@ -0,0 +1,62 @@
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBaseURL_Pick(t *testing.T) {
+	base := NewConfig(WithEjection(1, time.Minute)).NewBaseURL("http://a.example.com", "http://b.example.com")
+	first, err := base.Pick()
+	assert.Nil(t, err)
+	second, err := base.Pick()
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+
+	// a retry goes to another endpoint
+	retry, err := base.Pick(first)
+	assert.Nil(t, err)
+	assert.Equal(t, second, retry)
+
+	// an ejected endpoint is skipped
+	base.Report(first, nil, errors.New("connection refused"))
+	for i := 0; i < 3; i++ {
+		endpoint, _ := base.Pick()
+		assert.Equal(t, second, endpoint)
+	}
+	base.Report(second, &http.Response{StatusCode: http.StatusBadGateway}, nil)
+	endpoint, err := base.Pick()
+	assert.Nil(t, err)
+	assert.NotNil(t, endpoint)
+}
+
+// run with -race: failures are read while calls report them
+func TestBaseURL_Failures(t *testing.T) {
+	base := NewConfig(WithEjection(100, time.Minute)).NewBaseURL("http://a.example.com", "http://b.example.com")
+	var group sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			endpoint, err := base.Pick()
+			assert.Nil(t, err)
+			base.Report(endpoint, nil, errors.New("connection refused"))
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		base.Failures()
+	}
+	group.Wait()
+	failures := base.Failures()
+	assert.Len(t, failures, 2)
+	assert.Equal(t, 10, failures["http://a.example.com"]+failures["http://b.example.com"])
+}
+
+func TestLeastFailures(t *testing.T) {
+	endpoints := []*Endpoint{{failures: 2}, {failures: 0}, {failures: 1}}
+	assert.Equal(t, endpoints[1], LeastFailures().Pick(endpoints))
+}
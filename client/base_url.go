@@ -0,0 +1,245 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	InvalidBaseURL = "base url must be an absolute url with a host"
+	NoBaseURL      = "no base url"
+)
+
+const (
+	// an endpoint is ejected for DefaultCooldown after DefaultMaxFailures consecutive failures
+	DefaultMaxFailures = 3
+	DefaultCooldown    = 30 * time.Second
+
+	// urls of a resolver are cached for DefaultResolveInterval
+	DefaultResolveInterval = 30 * time.Second
+)
+
+type (
+	// BaseURL of a generated service: one or more endpoints picked by a Balancer, safe for concurrent use
+	BaseURL struct {
+		mutex       sync.Mutex
+		endpoints   []*Endpoint
+		err         error // returned by Pick until a valid url is set
+		balancer    Balancer
+		resolver    Resolver
+		maxFailures int
+		cooldown    time.Duration
+		interval    time.Duration // of resolutions
+		resolvedAt  time.Time
+		resolving   bool
+	}
+
+	// Resolver supplies base urls at runtime, overriding @Base. etc. service discovery
+	Resolver interface {
+		Resolve() (rawURLs []string, err error)
+	}
+)
+
+func InvalidBaseURLError(rawURL string) error {
+	return errors.New(InvalidBaseURL + ": " + rawURL)
+}
+
+// NewBaseURL keeps the parse error of rawURLs, so that every call fails with it
+func NewBaseURL(rawURLs ...string) *BaseURL {
+	base := &BaseURL{balancer: RoundRobin(), maxFailures: DefaultMaxFailures, cooldown: DefaultCooldown, interval: DefaultResolveInterval}
+	base.endpoints, base.err = parseEndpoints(rawURLs, nil)
+	return base
+}
+
+func ParseBaseURL(rawURLs ...string) (base *BaseURL, err error) {
+	base = NewBaseURL(rawURLs...)
+	if err = base.err; err != nil {
+		base = nil
+	}
+	return
+}
+
+func parseBaseURL(rawURL string) (baseURL *url.URL, err error) {
+	baseURL, err = url.Parse(rawURL)
+	if err == nil && (!baseURL.IsAbs() || baseURL.Host == "") {
+		err = InvalidBaseURLError(rawURL)
+	}
+	if err != nil {
+		baseURL = nil
+	}
+	return
+}
+
+// endpoints of rawURLs; states of old endpoints are kept
+func parseEndpoints(rawURLs []string, old []*Endpoint) (endpoints []*Endpoint, err error) {
+	if len(rawURLs) == 0 {
+		err = errors.New(NoBaseURL)
+	}
+	states := make(map[string]*Endpoint)
+	for _, endpoint := range old {
+		states[endpoint.url.String()] = endpoint
+	}
+	for _, rawURL := range rawURLs {
+		var baseURL *url.URL
+		if baseURL, err = parseBaseURL(rawURL); err != nil {
+			break
+		}
+		if endpoint, ok := states[baseURL.String()]; ok {
+			endpoints = append(endpoints, endpoint)
+		} else {
+			endpoints = append(endpoints, &Endpoint{url: baseURL})
+		}
+	}
+	if err != nil {
+		endpoints = nil
+	}
+	return
+}
+
+// Set replaces all endpoints and stops the resolver, which would overwrite them; invalid rawURLs leave them unchanged
+func (base *BaseURL) Set(rawURLs ...string) (err error) {
+	var endpoints []*Endpoint
+	if endpoints, err = parseEndpoints(rawURLs, nil); err == nil {
+		base.mutex.Lock()
+		base.endpoints, base.err, base.resolver = endpoints, nil, nil
+		base.mutex.Unlock()
+	}
+	return
+}
+
+// Pick an available endpoint except the excluded ones, which are tried by earlier attempts of the same call;
+// ejected endpoints are only picked when no other one is left
+func (base *BaseURL) Pick(excluded ...*Endpoint) (endpoint *Endpoint, err error) {
+	base.refresh()
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	if err = base.err; err != nil {
+		return
+	}
+	now := time.Now()
+	available, rest := make([]*Endpoint, 0), make([]*Endpoint, 0)
+	for _, candidate := range base.endpoints {
+		if isExcluded(candidate, excluded) {
+			continue
+		}
+		if candidate.ejectedUntil.After(now) {
+			rest = append(rest, candidate)
+		} else {
+			available = append(available, candidate)
+		}
+	}
+	switch {
+	case len(available) != 0:
+		endpoint = base.balancer.Pick(available)
+	case len(rest) != 0:
+		endpoint = base.balancer.Pick(rest)
+	default:
+		endpoint = base.balancer.Pick(base.endpoints)
+	}
+	return
+}
+
+// resolve urls once they are older than the interval, without holding the mutex;
+// only the first resolution is waited for, later ones run in the background
+func (base *BaseURL) refresh() {
+	base.mutex.Lock()
+	resolver, first := base.resolver, base.resolvedAt.IsZero()
+	due := resolver != nil && !base.resolving && time.Since(base.resolvedAt) >= base.interval
+	base.resolving = base.resolving || due
+	base.mutex.Unlock()
+	switch {
+	case due && first:
+		base.resolve(resolver)
+	case due:
+		go base.resolve(resolver)
+	}
+}
+
+// resolved urls replace endpoints unless Set stopped the resolver; a failed resolution keeps the last ones
+func (base *BaseURL) resolve(resolver Resolver) {
+	rawURLs, err := resolver.Resolve()
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	base.resolving, base.resolvedAt = false, time.Now()
+	if base.resolver == nil {
+		return
+	}
+	if err == nil {
+		var endpoints []*Endpoint
+		if endpoints, err = parseEndpoints(rawURLs, base.endpoints); err == nil {
+			base.endpoints, base.err = endpoints, nil
+		}
+	}
+	if err != nil && len(base.endpoints) == 0 {
+		base.err = err
+	}
+}
+
+func isExcluded(endpoint *Endpoint, excluded []*Endpoint) bool {
+	for _, item := range excluded {
+		if item == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// Report the result of a call to endpoint; an error or a 5xx status is a failure
+func (base *BaseURL) Report(endpoint *Endpoint, response *http.Response, err error) {
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	if IsFailure(response, err) {
+		endpoint.failures++
+		if endpoint.failures >= base.maxFailures {
+			endpoint.ejectedUntil = time.Now().Add(base.cooldown)
+		}
+	} else {
+		endpoint.failures = 0
+		endpoint.ejectedUntil = time.Time{}
+	}
+}
+
+// Failures in a row by the url of each endpoint. etc. for health checks
+func (base *BaseURL) Failures() map[string]int {
+	base.mutex.Lock()
+	defer base.mutex.Unlock()
+	failures := make(map[string]int, len(base.endpoints))
+	for _, endpoint := range base.endpoints {
+		failures[endpoint.url.String()] = endpoint.failures
+	}
+	return failures
+}
+
+// IsFailure of a call: an error or a 5xx status
+func IsFailure(response *http.Response, err error) bool {
+	return err != nil || response.StatusCode >= http.StatusInternalServerError
+}
+
+// Resolve uri by a picked endpoint
+func (base *BaseURL) Resolve(uri string) (resolved *url.URL, err error) {
+	var endpoint *Endpoint
+	if endpoint, err = base.Pick(); err == nil {
+		resolved, err = endpoint.Resolve(uri)
+	}
+	return
+}
+
+// Resolve uri relative to the path of endpoint. etc. https://host/api + /items?page=1 -> https://host/api/items?page=1
+func (endpoint *Endpoint) Resolve(uri string) (resolved *url.URL, err error) {
+	var ref *url.URL
+	if ref, err = url.Parse("./" + strings.TrimLeft(uri, "/")); err == nil {
+		baseURL := *endpoint.url
+		if !strings.HasSuffix(baseURL.Path, "/") {
+			baseURL.Path += "/"
+			if baseURL.RawPath != "" {
+				baseURL.RawPath += "/"
+			}
+		}
+		resolved = baseURL.ResolveReference(ref)
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type resolverFunc func() ([]string, error)
+
+func (resolve resolverFunc) Resolve() ([]string, error) {
+	return resolve()
+}
+
+func TestBaseURL_Resolve(t *testing.T) {
+	base := NewBaseURL("https://box.zjuqsc.com/item")
+	resolved, err := base.Resolve("/get/1?page=2")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://box.zjuqsc.com/item/get/1?page=2", resolved.String())
+
+	assert.NotNil(t, base.Set("box.zjuqsc.com"))
+	assert.Nil(t, base.Set("http://localhost:8080/"))
+	resolved, err = base.Resolve("get/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080/get/1", resolved.String())
+
+	_, err = NewBaseURL("://").Resolve("/")
+	assert.NotNil(t, err)
+}
+
+func TestBaseURL_Resolver(t *testing.T) {
+	calls := 0
+	resolver := resolverFunc(func() ([]string, error) {
+		calls++
+		return []string{"http://a.example.com"}, nil
+	})
+	base := NewConfig(WithResolver(resolver), WithResolveInterval(time.Hour)).NewBaseURL()
+	for i := 0; i < 3; i++ {
+		endpoint, err := base.Pick()
+		assert.Nil(t, err)
+		assert.Equal(t, "a.example.com", endpoint.url.Host)
+	}
+	// urls are cached for the interval
+	assert.Equal(t, 1, calls)
+
+	// and never overwrite urls of Set
+	assert.Nil(t, base.Set("http://b.example.com"))
+	base.resolvedAt = time.Time{}
+	endpoint, err := base.Pick()
+	assert.Nil(t, err)
+	assert.Equal(t, "b.example.com", endpoint.url.Host)
+	assert.Equal(t, 1, calls)
+}
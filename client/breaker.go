@@ -0,0 +1,109 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// a closed breaker opens after maxFailures consecutive failures;
+	// an open one lets a single probe through after the open duration, which closes or reopens it
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+type (
+	State int
+
+	// Breaker of a generated service or method, safe for concurrent use
+	Breaker struct {
+		name        string
+		maxFailures int
+		open        time.Duration
+		mutex       sync.Mutex
+		state       State
+		failures    int // consecutive
+		openedAt    time.Time
+	}
+
+	// CircuitOpenError is returned instead of making the request; errors.Is(err, ErrCircuitOpen) holds
+	CircuitOpenError struct {
+		Breaker string
+		Until   time.Time // a probe is allowed after it
+	}
+)
+
+func NewBreaker(name string, maxFailures int, open time.Duration) *Breaker {
+	return &Breaker{name: name, maxFailures: maxFailures, open: open}
+}
+
+func (state State) String() string {
+	switch state {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(state))
+}
+
+func (err *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s until %s", ErrCircuitOpen, err.Breaker, err.Until.Format(time.RFC3339))
+}
+
+func (err *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+func (breaker *Breaker) Name() string {
+	return breaker.name
+}
+
+// State for health checks; an open breaker past its open duration is reported half-open
+func (breaker *Breaker) State() State {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if breaker.state == Open && !time.Now().Before(breaker.openedAt.Add(breaker.open)) {
+		return HalfOpen
+	}
+	return breaker.state
+}
+
+// Allow a call, or return *CircuitOpenError
+func (breaker *Breaker) Allow() (err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	until := breaker.openedAt.Add(breaker.open)
+	switch {
+	case breaker.state == Open && !time.Now().Before(until):
+		breaker.state = HalfOpen
+	case breaker.state != Closed:
+		// open, or half-open with a probe in flight
+		err = &CircuitOpenError{Breaker: breaker.name, Until: until}
+	}
+	return
+}
+
+// Report the result of an allowed call
+func (breaker *Breaker) Report(response *http.Response, err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if !IsFailure(response, err) {
+		breaker.state, breaker.failures = Closed, 0
+		return
+	}
+	breaker.failures++
+	if breaker.state == HalfOpen || breaker.failures >= breaker.maxFailures {
+		breaker.state, breaker.openedAt = Open, time.Now()
+	}
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	breaker := NewBreaker("Service", 2, 20*time.Millisecond)
+	failure := errors.New("connection refused")
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, breaker.Allow())
+		breaker.Report(nil, failure)
+	}
+	assert.Equal(t, Open, breaker.State())
+	err := breaker.Allow()
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, HalfOpen, breaker.State())
+	assert.Nil(t, breaker.Allow())
+	// one probe at a time
+	assert.NotNil(t, breaker.Allow())
+	breaker.Report(&http.Response{StatusCode: http.StatusOK}, nil)
+	assert.Equal(t, Closed, breaker.State())
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"bytes"
+	"github.com/rady-io/http-service/headers"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheStore keeps cached responses by key; implementations must be safe for concurrent use
+	CacheStore interface {
+		Get(key string) (entry *CacheEntry, ok bool)
+		Set(key string, entry *CacheEntry)
+		Delete(key string)
+	}
+
+	CacheEntry struct {
+		StatusCode int
+		Header     http.Header
+		Body       []byte
+		Vary       http.Header // request headers named by Vary of response
+		Expires    time.Time   // stale after it, then revalidated by ETag or Last-Modified
+	}
+
+	// Cache of GET and HEAD responses, honoring Cache-Control, ETag and Last-Modified
+	Cache struct {
+		store CacheStore
+	}
+
+	// the call to make on a miss or revalidation
+	DoFunc func(request *http.Request) (*http.Response, error)
+
+	memoryStore struct {
+		mutex   sync.RWMutex
+		entries map[string]*CacheEntry
+	}
+)
+
+func NewCache(store CacheStore) *Cache {
+	return &Cache{store: store}
+}
+
+// NewMemoryStore keeps entries in memory until they are replaced or deleted
+func NewMemoryStore() CacheStore {
+	return &memoryStore{entries: make(map[string]*CacheEntry)}
+}
+
+func (store *memoryStore) Get(key string) (entry *CacheEntry, ok bool) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	entry, ok = store.entries[key]
+	return
+}
+
+func (store *memoryStore) Set(key string, entry *CacheEntry) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.entries[key] = entry
+}
+
+func (store *memoryStore) Delete(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.entries, key)
+}
+
+// Do serves request from the cache while fresh, revalidates a stale entry, or calls do;
+// ttl is the freshness of responses without max-age or Expires
+func (cache *Cache) Do(request *http.Request, ttl time.Duration, do DoFunc) (response *http.Response, err error) {
+	requestControl := parseCacheControl(request.Header)
+	if request.Method != http.MethodGet && request.Method != http.MethodHead || requestControl.has("no-store") {
+		return do(request)
+	}
+	key := request.Method + " " + request.URL.String()
+	entry, ok := cache.store.Get(key)
+	if ok && !entry.matchVary(request) {
+		entry, ok = nil, false
+	}
+	if ok && !requestControl.has("no-cache") && time.Now().Before(entry.Expires) {
+		return entry.response(request), nil
+	}
+	if ok {
+		if etag := entry.Header.Get(headers.HeaderETag); etag != "" {
+			request.Header.Set(headers.HeaderIfNoneMatch, etag)
+		}
+		if lastModified := entry.Header.Get(headers.HeaderLastModified); lastModified != "" {
+			request.Header.Set(headers.HeaderIfModifiedSince, lastModified)
+		}
+	}
+
+	if response, err = do(request); err != nil {
+		return
+	}
+	if ok && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		// the stored entry may be read by concurrent hits; update a copy
+		revalidated := *entry
+		revalidated.Header = entry.Header.Clone()
+		for field, values := range response.Header {
+			revalidated.Header[field] = values
+		}
+		revalidated.Expires = expiresOf(response.Header, ttl)
+		cache.store.Set(key, &revalidated)
+		return revalidated.response(request), nil
+	}
+	if response.StatusCode != http.StatusOK || parseCacheControl(response.Header).has("no-store") {
+		return
+	}
+
+	var body []byte
+	body, err = ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry = &CacheEntry{
+		StatusCode: response.StatusCode,
+		Header:     response.Header.Clone(),
+		Body:       body,
+		Vary:       make(http.Header),
+		Expires:    expiresOf(response.Header, ttl),
+	}
+	for _, name := range response.Header.Values(headers.HeaderVary) {
+		for _, field := range strings.Split(name, ",") {
+			field = http.CanonicalHeaderKey(strings.TrimSpace(field))
+			entry.Vary[field] = request.Header.Values(field)
+		}
+	}
+	if _, varyAll := entry.Vary["*"]; !varyAll {
+		cache.store.Set(key, entry)
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return
+}
+
+func (entry *CacheEntry) matchVary(request *http.Request) bool {
+	for field, values := range entry.Vary {
+		if strings.Join(values, ",") != strings.Join(request.Header.Values(field), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (entry *CacheEntry) response(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       request,
+	}
+}
+
+// max-age, then Expires, then ttl; no-cache makes it stale at once
+func expiresOf(header http.Header, ttl time.Duration) time.Time {
+	now := time.Now()
+	control := parseCacheControl(header)
+	if control.has("no-cache") {
+		return now
+	}
+	if maxAge, ok := control["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil {
+			return now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	if expires, err := http.ParseTime(header.Get(headers.HeaderExpires)); err == nil {
+		return expires
+	}
+	return now.Add(ttl)
+}
+
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	control := make(cacheControl)
+	for _, value := range header.Values(headers.HeaderCacheControl) {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if equal := strings.Index(directive, "="); equal != -1 {
+				control[strings.ToLower(directive[:equal])] = strings.Trim(directive[equal+1:], `"`)
+			} else if directive != "" {
+				control[strings.ToLower(directive)] = ""
+			}
+		}
+	}
+	return control
+}
+
+func (control cacheControl) has(directive string) bool {
+	_, ok := control[directive]
+	return ok
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"github.com/rady-io/http-service/headers"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordStore struct {
+	CacheStore
+	entries []*CacheEntry
+}
+
+func (store *recordStore) Set(key string, entry *CacheEntry) {
+	store.entries = append(store.entries, entry)
+	store.CacheStore.Set(key, entry)
+}
+
+func TestCache_Do(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+		if request.Header.Get(headers.HeaderIfNoneMatch) == `"v1"` {
+			writer.Header().Set("X-Revalidated", "1")
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writer.Header().Set(headers.HeaderETag, `"v1"`)
+		writer.Header().Set(headers.HeaderCacheControl, "max-age=0")
+		writer.Write([]byte("items"))
+	}))
+	defer server.Close()
+
+	store := &recordStore{CacheStore: NewMemoryStore()}
+	cache := NewCache(store)
+	do := func(request *http.Request) (*http.Response, error) {
+		return server.Client().Do(request)
+	}
+	for i := 0; i < 2; i++ {
+		request, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+		response, err := cache.Do(request, time.Minute, do)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		body, _ := ioutil.ReadAll(response.Body)
+		assert.Equal(t, "items", string(body))
+	}
+	// the stale entry is revalidated by ETag
+	assert.Equal(t, 2, calls)
+	// into a copy, as the stored entry may be read concurrently
+	assert.Len(t, store.entries, 2)
+	assert.Empty(t, store.entries[0].Header.Get("X-Revalidated"))
+	assert.Equal(t, "1", store.entries[1].Header.Get("X-Revalidated"))
+}
+
+func TestCache_Fresh(t *testing.T) {
+	calls := 0
+	cache := NewCache(NewMemoryStore())
+	do := func(request *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: http.NoBody}, nil
+	}
+	for i := 0; i < 3; i++ {
+		request, _ := http.NewRequest(http.MethodGet, "http://example.com/items", nil)
+		_, err := cache.Do(request, time.Minute, do)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, calls)
+}
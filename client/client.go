@@ -0,0 +1,135 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+type (
+	// Config is shared by all methods of a generated service
+	Config struct {
+		httpClient   *http.Client
+		jar          http.CookieJar
+		balancer     Balancer
+		resolver     Resolver
+		interval     time.Duration // of resolutions
+		maxFailures  int
+		cooldown     time.Duration
+		cache        *Cache
+		flights      *Singleflight
+		tracer       Tracer
+		metrics      Metrics
+		logger       CallLogger
+		previewLimit int
+	}
+
+	// Option is the variadic argument of a generated constructor
+	Option func(config *Config)
+)
+
+func NewConfig(options ...Option) *Config {
+	config := &Config{
+		httpClient:  new(http.Client),
+		maxFailures: DefaultMaxFailures,
+		cooldown:    DefaultCooldown,
+		interval:    DefaultResolveInterval,
+		cache:       NewCache(NewMemoryStore()),
+		flights:     NewSingleflight(),
+		tracer:      noopTracer{},
+		metrics:     noopMetrics{},
+		logger:      noopLogger{},
+	}
+	for _, option := range options {
+		option(config)
+	}
+	if config.jar != nil {
+		httpClient := *config.httpClient
+		httpClient.Jar = config.jar
+		config.httpClient = &httpClient
+	}
+	return config
+}
+
+// WithHTTPClient replaces the default http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(config *Config) {
+		config.httpClient = httpClient
+	}
+}
+
+// WithCookieJar stores Set-Cookie of responses and replays them on later calls
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(config *Config) {
+		config.jar = jar
+	}
+}
+
+// WithBalancer picks endpoints of @Base by balancer instead of RoundRobin
+func WithBalancer(balancer Balancer) Option {
+	return func(config *Config) {
+		config.balancer = balancer
+	}
+}
+
+// WithEjection ejects an endpoint for cooldown after maxFailures consecutive failures
+func WithEjection(maxFailures int, cooldown time.Duration) Option {
+	return func(config *Config) {
+		config.maxFailures, config.cooldown = maxFailures, cooldown
+	}
+}
+
+// WithResolver overrides @Base by urls of resolver, which are cached for DefaultResolveInterval
+func WithResolver(resolver Resolver) Option {
+	return func(config *Config) {
+		config.resolver = resolver
+	}
+}
+
+// WithResolveInterval caches urls of the resolver for interval
+func WithResolveInterval(interval time.Duration) Option {
+	return func(config *Config) {
+		config.interval = interval
+	}
+}
+
+// WithCacheStore keeps responses of @Cache methods in store instead of memory
+func WithCacheStore(store CacheStore) Option {
+	return func(config *Config) {
+		config.cache = NewCache(store)
+	}
+}
+
+// NewBaseURL with the balancer, ejection and resolver of config
+func (config *Config) NewBaseURL(rawURLs ...string) *BaseURL {
+	base := NewBaseURL(rawURLs...)
+	if config.balancer != nil {
+		base.balancer = config.balancer
+	}
+	base.maxFailures, base.cooldown = config.maxFailures, config.cooldown
+	base.resolver, base.interval = config.resolver, config.interval
+	return base
+}
+
+func (config *Config) ParseBaseURL(rawURLs ...string) (base *BaseURL, err error) {
+	base = config.NewBaseURL(rawURLs...)
+	if err = base.err; err != nil {
+		base = nil
+	}
+	return
+}
+
+func (config *Config) Client() *http.Client {
+	return config.httpClient
+}
+
+func (config *Config) Cache() *Cache {
+	return config.cache
+}
+
+func (config *Config) Singleflight() *Singleflight {
+	return config.flights
+}
+
+func (config *Config) Tracer() Tracer {
+	return config.tracer
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AddCookie adds cookie to request only if its Secure, Domain, Path and MaxAge attributes match the request
+func AddCookie(request *http.Request, cookie *http.Cookie) {
+	if MatchCookie(request, cookie) {
+		request.AddCookie(cookie)
+	}
+}
+
+func MatchCookie(request *http.Request, cookie *http.Cookie) bool {
+	if cookie.MaxAge < 0 || cookie.Secure && request.URL.Scheme != "https" {
+		return false
+	}
+	return matchDomain(request.URL.Hostname(), cookie.Domain) && matchPath(request.URL.Path, cookie.Path)
+}
+
+// domain matching of RFC 6265; an empty domain matches any host
+func matchDomain(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	host = strings.ToLower(host)
+	return domain == "" || host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// path matching of RFC 6265; an empty path matches any path
+func matchPath(requestPath, cookiePath string) bool {
+	if cookiePath == "" || cookiePath == "/" {
+		return true
+	}
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return len(requestPath) == len(cookiePath) ||
+		strings.HasSuffix(cookiePath, "/") ||
+		requestPath[len(cookiePath)] == '/'
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchCookie(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "https://api.example.com/items/1", nil)
+	assert.True(t, MatchCookie(request, &http.Cookie{Name: "a"}))
+	assert.True(t, MatchCookie(request, &http.Cookie{Name: "a", Domain: ".example.com", Path: "/items", Secure: true}))
+	assert.False(t, MatchCookie(request, &http.Cookie{Name: "a", Domain: "other.com"}))
+	assert.False(t, MatchCookie(request, &http.Cookie{Name: "a", Path: "/item"}))
+	assert.False(t, MatchCookie(request, &http.Cookie{Name: "a", MaxAge: -1}))
+
+	plain := httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+	assert.False(t, MatchCookie(plain, &http.Cookie{Name: "a", Secure: true}))
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Curl renders request as a reproducible curl command with its headers, cookies and body;
+// the body is read by GetBody, or read and restored if GetBody is nil;
+// a binary body, such as a compressed or multipart one, is piped to curl by printf
+func Curl(request *http.Request) (command string, err error) {
+	var body []byte
+	if body, err = peekBody(request); err != nil {
+		return
+	}
+	args := []string{"curl"}
+	switch {
+	case request.Method == http.MethodHead:
+		args = append(args, "--head")
+	case request.Method != "" && (request.Method != http.MethodGet || body != nil):
+		args = append(args, "-X", request.Method)
+	}
+	args = append(args, quote(request.URL.String()))
+
+	keys := make([]string, 0, len(request.Header))
+	for key := range request.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range request.Header[key] {
+			args = append(args, "-H", quote(key+": "+value))
+		}
+	}
+	if request.Host != "" && request.Host != request.URL.Host {
+		args = append(args, "-H", quote("Host: "+request.Host))
+	}
+	switch {
+	case body == nil:
+	case isText(body):
+		args = append(args, "--data-binary", quote(string(body)))
+	default:
+		args = append(args, "--data-binary", "@-")
+		command = "printf " + quote(octalEscape(body)) + " | "
+	}
+	command += strings.Join(args, " ")
+	return
+}
+
+// Curl of request with cookies of the cookie jar, as the generated client would send them
+func (config *Config) Curl(request *http.Request) (command string, err error) {
+	if config.jar != nil {
+		cloned := request.Clone(request.Context())
+		for _, cookie := range config.jar.Cookies(request.URL) {
+			cloned.AddCookie(cookie)
+		}
+		command, err = Curl(cloned)
+		// an unreplayable body is read and restored on the clone
+		request.Body = cloned.Body
+		return
+	}
+	return Curl(request)
+}
+
+// nil if request has no body
+func peekBody(request *http.Request) (body []byte, err error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return
+	}
+	var reader io.ReadCloser
+	if request.GetBody != nil {
+		reader, err = request.GetBody()
+	}
+	if err == nil {
+		if reader == nil {
+			if body, err = ioutil.ReadAll(request.Body); err == nil {
+				request.Body.Close()
+				request.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+		} else {
+			body, err = ioutil.ReadAll(reader)
+			reader.Close()
+		}
+	}
+	return
+}
+
+// valid utf-8 without control characters other than tab and line breaks
+func isText(body []byte) bool {
+	if !utf8.Valid(body) {
+		return false
+	}
+	for _, char := range body {
+		if char < ' ' && char != '\t' && char != '\n' && char != '\r' || char == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// format of printf writing body as is; bytes other than printable ascii are escaped in octal
+func octalEscape(body []byte) string {
+	var builder strings.Builder
+	for _, char := range body {
+		if char >= ' ' && char < 0x7f && char != '\\' && char != '%' {
+			builder.WriteByte(char)
+		} else {
+			fmt.Fprintf(&builder, `\%03o`, char)
+		}
+	}
+	return builder.String()
+}
+
+// single-quoted for POSIX shells
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"github.com/rady-io/http-service/compression"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCurl(t *testing.T) {
+	request, err := http.NewRequest(http.MethodPut, "http://box.zjuqsc.com/item/1?tag=a", strings.NewReader(`{"name":"it's"}`))
+	assert.Nil(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	request.AddCookie(&http.Cookie{Name: "qsc_session", Value: "id"})
+
+	command, err := Curl(request)
+	assert.Nil(t, err)
+	assert.Equal(t, `curl -X PUT 'http://box.zjuqsc.com/item/1?tag=a' -H 'Content-Type: application/json' -H 'Cookie: qsc_session=id' --data-binary '{"name":"it'\''s"}'`, command)
+	body, err := ioutil.ReadAll(request.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"it's"}`, string(body))
+}
+
+func TestCurl_Binary(t *testing.T) {
+	compressed, err := compression.Compress("gzip", strings.NewReader(`{"name":"100%\\it's"}`))
+	assert.Nil(t, err)
+	body := compressed.Bytes()
+	request, err := http.NewRequest(http.MethodPost, "http://box.zjuqsc.com/item", compressed)
+	assert.Nil(t, err)
+	request.Header.Set("Content-Encoding", "gzip")
+
+	command, err := Curl(request)
+	assert.Nil(t, err)
+	pipe := strings.SplitN(command, " | ", 2)
+	assert.Len(t, pipe, 2)
+	assert.Equal(t, `curl -X POST 'http://box.zjuqsc.com/item' -H 'Content-Encoding: gzip' --data-binary @-`, pipe[1])
+	// printf writes the compressed body as is
+	output, err := exec.Command("sh", "-c", pipe[0]).Output()
+	assert.Nil(t, err)
+	assert.Equal(t, body, output)
+}
+
+func TestConfig_Curl(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	assert.Nil(t, err)
+	base, _ := url.Parse("http://box.zjuqsc.com/item")
+	jar.SetCookies(base, []*http.Cookie{{Name: "token", Value: "secret", Path: "/item"}})
+	config := NewConfig(WithCookieJar(jar))
+	request, err := http.NewRequest(http.MethodGet, "http://box.zjuqsc.com/item/1", ioutil.NopCloser(strings.NewReader("body")))
+	assert.Nil(t, err)
+
+	command, err := config.Curl(request)
+	assert.Nil(t, err)
+	assert.Equal(t, `curl -X GET 'http://box.zjuqsc.com/item/1' -H 'Cookie: token=secret' --data-binary 'body'`, command)
+	assert.Empty(t, request.Header)
+	body, err := ioutil.ReadAll(request.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "body", string(body))
+}
@@ -0,0 +1,12 @@
+package client
+
+import "encoding"
+
+// MarshalText formats a param implementing encoding.TextMarshaler for uri, query, header, cookie or form
+func MarshalText(value encoding.TextMarshaler) (text string, err error) {
+	var data []byte
+	if data, err = value.MarshalText(); err == nil {
+		text = string(data)
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+var (
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+type (
+	// Limiter is a token bucket of a generated service or method, safe for concurrent use
+	Limiter struct {
+		name         string
+		rate         float64 // tokens per second
+		burst        float64
+		failFast     bool
+		adaptive     bool
+		mutex        sync.Mutex
+		tokens       float64
+		last         time.Time
+		blockedUntil time.Time // from response headers, only if adaptive
+	}
+)
+
+// NewLimiter allows rate calls per second with bursts of burst calls
+func NewLimiter(name string, rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{name: name, rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// FailFast returns ErrRateLimited instead of waiting for a token
+func (limiter *Limiter) FailFast() *Limiter {
+	limiter.failFast = true
+	return limiter
+}
+
+// Adaptive pauses calls as told by Retry-After and X-RateLimit-* of responses
+func (limiter *Limiter) Adaptive() *Limiter {
+	limiter.adaptive = true
+	return limiter
+}
+
+func (limiter *Limiter) Name() string {
+	return limiter.name
+}
+
+// Wait for a token until ctx is done
+func (limiter *Limiter) Wait(ctx context.Context) (err error) {
+	delay, ok := limiter.reserve()
+	if !ok {
+		return ErrRateLimited
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			limiter.cancel()
+			err = ctx.Err()
+		}
+	}
+	return
+}
+
+// take a token, which may be borrowed from the future; fail fast if it is not available now
+func (limiter *Limiter) reserve() (delay time.Duration, ok bool) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := time.Now()
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.rate)
+	limiter.last = now
+	if limiter.tokens < 1 {
+		delay = time.Duration((1 - limiter.tokens) / limiter.rate * float64(time.Second))
+	}
+	if limiter.blockedUntil.After(now.Add(delay)) {
+		delay = limiter.blockedUntil.Sub(now)
+	}
+	if delay > 0 && limiter.failFast {
+		return 0, false
+	}
+	limiter.tokens--
+	return delay, true
+}
+
+// return the token of a canceled wait
+func (limiter *Limiter) cancel() {
+	limiter.mutex.Lock()
+	limiter.tokens++
+	limiter.mutex.Unlock()
+}
+
+// Observe rate limit headers of response if adaptive
+func (limiter *Limiter) Observe(response *http.Response) {
+	if !limiter.adaptive || response == nil {
+		return
+	}
+	now := time.Now()
+	var until time.Time
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable {
+		until = parseRetryAfter(response.Header.Get(HeaderRetryAfter), now)
+	}
+	if remaining := response.Header.Get(HeaderRateLimitRemaining); until.IsZero() && remaining == "0" {
+		until = parseReset(response.Header.Get(HeaderRateLimitReset), now)
+	}
+	if !until.IsZero() {
+		limiter.mutex.Lock()
+		if until.After(limiter.blockedUntil) {
+			limiter.blockedUntil = until
+		}
+		limiter.mutex.Unlock()
+	}
+}
+
+// seconds or an http date
+func parseRetryAfter(value string, now time.Time) (until time.Time) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		until = now.Add(time.Duration(seconds) * time.Second)
+	} else if date, err := http.ParseTime(value); err == nil {
+		until = date
+	}
+	return
+}
+
+// seconds to wait, or a unix timestamp
+func parseReset(value string, now time.Time) (until time.Time) {
+	if reset, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if reset > now.Unix()/2 {
+			until = time.Unix(reset, 0)
+		} else {
+			until = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return
+}
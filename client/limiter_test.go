@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter("Service", 100, 2)
+	ctx := context.Background()
+	assert.Nil(t, limiter.Wait(ctx))
+	assert.Nil(t, limiter.Wait(ctx))
+	start := time.Now()
+	assert.Nil(t, limiter.Wait(ctx))
+	assert.True(t, time.Since(start) >= 5*time.Millisecond)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, NewLimiter("Service", 1, 1).reserveAndWait(canceled))
+
+	failFast := NewLimiter("Service", 1, 1).FailFast()
+	assert.Nil(t, failFast.Wait(ctx))
+	assert.Equal(t, ErrRateLimited, failFast.Wait(ctx))
+}
+
+func TestLimiter_Observe(t *testing.T) {
+	limiter := NewLimiter("Service", 1000, 10).Adaptive().FailFast()
+	limiter.Observe(&http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{HeaderRetryAfter: []string{"1"}},
+	})
+	assert.Equal(t, ErrRateLimited, limiter.Wait(context.Background()))
+}
+
+// drain the bucket, then wait
+func (limiter *Limiter) reserveAndWait(ctx context.Context) error {
+	limiter.reserve()
+	return limiter.Wait(ctx)
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bytes"
+	"github.com/rady-io/http-service/compression"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// bytes of request and response bodies kept for a CallRecord
+	DefaultPreviewLimit = 1024
+)
+
+type (
+	// CallLogger records every outbound call; see WithLogger for log/slog
+	CallLogger interface {
+		LogCall(call *Call, record *CallRecord)
+	}
+
+	// bodies are previewed decoded by their Content-Encoding
+	CallRecord struct {
+		Request      *http.Request
+		RequestBody  []byte         // preview, nil if the body cannot be read again
+		Response     *http.Response // nil if the call failed
+		ResponseBody []byte         // preview of the body read by the caller
+		Duration     time.Duration  // until the response body is closed
+		Err          error
+	}
+
+	noopLogger struct{}
+
+	// keeps a preview of the body and logs the call when closed
+	previewBody struct {
+		io.ReadCloser
+		once    sync.Once
+		limit   int
+		preview *bytes.Buffer
+		encoded *io.PipeWriter // feeds the decoder of an encoded body; nil if the body is not encoded
+		decoded chan struct{}  // closed when the decoder is done with the preview
+		full    bool           // the decoder needs no more bytes
+		done    func()
+	}
+)
+
+// WithCallLogger records calls by logger with body previews of previewLimit bytes
+func WithCallLogger(logger CallLogger, previewLimit int) Option {
+	return func(config *Config) {
+		config.logger = logger
+		config.previewLimit = previewLimit
+	}
+}
+
+func (noopLogger) LogCall(call *Call, record *CallRecord) {}
+
+// Log a call started at start: a failed call is logged at once, otherwise when the response body is closed
+func (config *Config) Log(call *Call, request *http.Request, start time.Time, response *http.Response, err error) {
+	if _, noop := config.logger.(noopLogger); noop {
+		return
+	}
+	record := &CallRecord{Request: request, Response: response, Err: err}
+	if request.GetBody != nil {
+		if body, bodyErr := request.GetBody(); bodyErr == nil {
+			record.RequestBody = config.preview(body, compression.Encodings(request.Header))
+		}
+	}
+	if err != nil {
+		record.Duration = time.Since(start)
+		config.logger.LogCall(call, record)
+		return
+	}
+	body := newPreviewBody(response.Body, config.previewLimit, compression.Encodings(response.Header))
+	body.done = func() {
+		record.Duration = time.Since(start)
+		record.ResponseBody = body.preview.Bytes()
+		config.logger.LogCall(call, record)
+	}
+	response.Body = body
+}
+
+// previewLimit bytes of body decoded by encodings
+func (config *Config) preview(body io.ReadCloser, encodings []string) (preview []byte) {
+	defer body.Close()
+	decoded, err := compression.Decode(body, encodings)
+	if err == nil {
+		preview, _ = io.ReadAll(io.LimitReader(decoded, int64(config.previewLimit)))
+	}
+	return
+}
+
+// the caller reads the body as is; an encoded body is decoded for the preview on the side
+func newPreviewBody(body io.ReadCloser, limit int, encodings []string) *previewBody {
+	preview := &previewBody{ReadCloser: body, limit: limit, preview: new(bytes.Buffer)}
+	if len(encodings) != 0 {
+		reader, writer := io.Pipe()
+		preview.encoded, preview.decoded = writer, make(chan struct{})
+		go func() {
+			defer close(preview.decoded)
+			if decoded, err := compression.Decode(reader, encodings); err == nil {
+				io.Copy(preview.preview, io.LimitReader(decoded, int64(limit)))
+			}
+			reader.Close()
+		}()
+	}
+	return preview
+}
+
+func (body *previewBody) Read(p []byte) (n int, err error) {
+	n, err = body.ReadCloser.Read(p)
+	if body.encoded != nil {
+		if !body.full {
+			_, writeErr := body.encoded.Write(p[:n])
+			body.full = writeErr != nil
+		}
+		if err != nil {
+			body.encoded.Close()
+		}
+	} else if left := body.limit - body.preview.Len(); left > 0 {
+		if left > n {
+			left = n
+		}
+		body.preview.Write(p[:left])
+	}
+	return
+}
+
+func (body *previewBody) Close() error {
+	body.once.Do(func() {
+		if body.encoded != nil {
+			body.encoded.Close()
+			<-body.decoded
+		}
+		body.done()
+	})
+	return body.ReadCloser.Close()
+}
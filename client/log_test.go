@@ -0,0 +1,47 @@
+package client
+
+import (
+	"bytes"
+	"github.com/rady-io/http-service/compression"
+	"github.com/rady-io/http-service/headers"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type recordLogger struct {
+	records []*CallRecord
+}
+
+func (logger *recordLogger) LogCall(call *Call, record *CallRecord) {
+	logger.records = append(logger.records, record)
+}
+
+func TestConfig_Log(t *testing.T) {
+	logger := new(recordLogger)
+	config := NewConfig(WithCallLogger(logger, 4))
+	compressed, err := compression.Compress(compression.Gzip, bytes.NewBufferString("request"))
+	assert.Nil(t, err)
+	request, err := http.NewRequest(http.MethodPost, "http://box.zjuqsc.com/item", compressed)
+	assert.Nil(t, err)
+	request.Header.Set(headers.HeaderContentEncoding, compression.Gzip)
+	compressed, err = compression.Compress(compression.Gzip, bytes.NewBufferString("response"))
+	assert.Nil(t, err)
+	response := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: ioutil.NopCloser(compressed)}
+	response.Header.Set(headers.HeaderContentEncoding, compression.Gzip)
+
+	// as generated code, the response is decompressed after it is logged
+	config.Log(&Call{Method: "PostItem"}, request, time.Now(), response, nil)
+	assert.Nil(t, compression.Decompress(response))
+	body, err := ioutil.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "response", string(body))
+	assert.Nil(t, response.Body.Close())
+
+	// previews are decoded
+	assert.Len(t, logger.records, 1)
+	assert.Equal(t, "requ", string(logger.records[0].RequestBody))
+	assert.Equal(t, "resp", string(logger.records[0].ResponseBody))
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Metrics observes every outbound call
+	Metrics interface {
+		Observe(call *Call, observation *Observation)
+	}
+
+	Observation struct {
+		Duration   time.Duration // until the response body is closed
+		StatusCode int           // 0 if the call failed
+		Err        error
+		BytesOut   int64 // request body; 0 if unknown
+		BytesIn    int64 // response body read by the caller
+	}
+
+	noopMetrics struct{}
+
+	// counts bytes read and observes the call when closed
+	measuredBody struct {
+		io.ReadCloser
+		once        sync.Once
+		observe     func()
+		observation *Observation
+	}
+)
+
+// WithMetrics observes calls by metrics instead of doing nothing
+func WithMetrics(metrics Metrics) Option {
+	return func(config *Config) {
+		config.metrics = metrics
+	}
+}
+
+func (noopMetrics) Observe(call *Call, observation *Observation) {}
+
+// Measure a call started at start: a failed call is observed at once, otherwise when the response body is closed
+func (config *Config) Measure(call *Call, request *http.Request, start time.Time, response *http.Response, err error) {
+	if _, noop := config.metrics.(noopMetrics); noop {
+		return
+	}
+	observation := &Observation{Err: err}
+	if request.ContentLength > 0 {
+		observation.BytesOut = request.ContentLength
+	}
+	if err != nil {
+		observation.Duration = time.Since(start)
+		config.metrics.Observe(call, observation)
+		return
+	}
+	observation.StatusCode = response.StatusCode
+	response.Body = &measuredBody{
+		ReadCloser:  response.Body,
+		observation: observation,
+		observe: func() {
+			observation.Duration = time.Since(start)
+			config.metrics.Observe(call, observation)
+		},
+	}
+}
+
+func (body *measuredBody) Read(p []byte) (n int, err error) {
+	n, err = body.ReadCloser.Read(p)
+	body.observation.BytesIn += int64(n)
+	return
+}
+
+func (body *measuredBody) Close() error {
+	body.once.Do(body.observe)
+	return body.ReadCloser.Close()
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordMetrics []*Observation
+
+func (metrics *recordMetrics) Observe(call *Call, observation *Observation) {
+	*metrics = append(*metrics, observation)
+}
+
+func TestConfig_Measure(t *testing.T) {
+	metrics := make(recordMetrics, 0)
+	config := NewConfig(WithMetrics(&metrics))
+	call := &Call{Service: "Service", Method: "GetItem", HTTPMethod: http.MethodGet, Route: "/item/{id}"}
+	request := httptest.NewRequest(http.MethodPost, "/item/1", strings.NewReader("body"))
+	response := &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("content"))}
+
+	config.Measure(call, request, time.Now(), response, nil)
+	assert.Len(t, metrics, 0)
+	_, err := io.Copy(ioutil.Discard, response.Body)
+	assert.Nil(t, err)
+	assert.Nil(t, response.Body.Close())
+	assert.Nil(t, response.Body.Close())
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, http.StatusOK, metrics[0].StatusCode)
+	assert.Equal(t, int64(4), metrics[0].BytesOut)
+	assert.Equal(t, int64(7), metrics[0].BytesIn)
+
+	config.Measure(call, request, time.Now(), nil, errors.New("refused"))
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, 0, metrics[1].StatusCode)
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// Retry of a failed call, i.e. an error or a 5xx status; each retry goes to an endpoint not tried yet while any is left
+	Retry struct {
+		Attempts int           // in total, including the first one
+		Backoff  time.Duration // before each retry
+	}
+
+	// AttemptFunc sends request to endpoint
+	AttemptFunc func(endpoint *Endpoint, request *http.Request) (*http.Response, error)
+)
+
+// Do request by attempt, then retry with a copy of request resolving uri by another endpoint of base;
+// a request whose body cannot be replayed is not retried
+func (retry Retry) Do(base *BaseURL, endpoint *Endpoint, uri string, request *http.Request, attempt AttemptFunc) (response *http.Response, err error) {
+	tried := []*Endpoint{endpoint}
+	for i := 1; ; i++ {
+		response, err = attempt(endpoint, request)
+		if i >= retry.Attempts || !IsFailure(response, err) || request.Body != nil && request.GetBody == nil {
+			return
+		}
+		next, nextRequest, nextErr := retry.next(base, tried, uri, request)
+		if nextErr != nil || !sleep(request.Context(), retry.Backoff) {
+			return
+		}
+		if response != nil {
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+		tried = append(tried, next)
+		endpoint, request = next, nextRequest
+	}
+}
+
+func (retry Retry) next(base *BaseURL, tried []*Endpoint, uri string, request *http.Request) (endpoint *Endpoint, next *http.Request, err error) {
+	var resolved *url.URL
+	if endpoint, err = base.Pick(tried...); err == nil {
+		resolved, err = endpoint.Resolve(uri)
+	}
+	if err == nil {
+		// the query of request also has the fields added after resolving uri
+		resolved.RawQuery = request.URL.RawQuery
+		next = request.Clone(request.Context())
+		next.URL, next.Host = resolved, resolved.Host
+		if request.GetBody != nil {
+			next.Body, err = request.GetBody()
+		}
+	}
+	return
+}
+
+// false if ctx is done first
+func sleep(ctx context.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetry_Do(t *testing.T) {
+	bodies := make([]string, 0)
+	handler := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			body, _ := ioutil.ReadAll(request.Body)
+			bodies = append(bodies, request.Host+" "+request.URL.RawQuery+" "+string(body))
+			writer.WriteHeader(status)
+		}))
+	}
+	down, up := handler(http.StatusBadGateway), handler(http.StatusOK)
+	defer down.Close()
+	defer up.Close()
+
+	base := NewBaseURL(down.URL, up.URL)
+	endpoint := base.endpoints[0]
+	request, _ := http.NewRequest(http.MethodPost, down.URL+"/items?page=2", bytes.NewBufferString("item"))
+	attempt := func(endpoint *Endpoint, request *http.Request) (*http.Response, error) {
+		return http.DefaultClient.Do(request)
+	}
+	// the query was added after resolving uri
+	response, err := Retry{Attempts: 3}.Do(base, endpoint, "/items", request, attempt)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	// the retry goes to another endpoint with the same query and body
+	assert.Equal(t, []string{request.Host + " page=2 item", response.Request.Host + " page=2 item"}, bodies)
+	assert.NotEqual(t, request.Host, response.Request.Host)
+
+	// a body which cannot be replayed is sent once
+	bodies = bodies[:0]
+	request, _ = http.NewRequest(http.MethodPost, down.URL+"/items", ioutil.NopCloser(bytes.NewBufferString("item")))
+	response, err = Retry{Attempts: 3}.Do(base, endpoint, "/items", request, attempt)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	assert.Len(t, bodies, 1)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// Singleflight shares one in-flight request among concurrent identical calls;
+	// the shared call is made with the request, and so the context, of the first caller
+	Singleflight struct {
+		mutex   sync.Mutex
+		flights map[string]*flight
+	}
+
+	flight struct {
+		done  sync.WaitGroup
+		entry *CacheEntry
+		err   error
+	}
+)
+
+func NewSingleflight() *Singleflight {
+	return &Singleflight{flights: make(map[string]*flight)}
+}
+
+// Do calls do once for concurrent requests with the same method, final url and headers;
+// each caller receives its own copy of the response
+func (group *Singleflight) Do(request *http.Request, do DoFunc) (*http.Response, error) {
+	key := flightKey(request)
+	group.mutex.Lock()
+	if call, ok := group.flights[key]; ok {
+		group.mutex.Unlock()
+		call.done.Wait()
+		return call.response(request)
+	}
+	call := new(flight)
+	call.done.Add(1)
+	group.flights[key] = call
+	group.mutex.Unlock()
+
+	call.entry, call.err = readEntry(do(request))
+	group.mutex.Lock()
+	delete(group.flights, key)
+	group.mutex.Unlock()
+	call.done.Done()
+	return call.response(request)
+}
+
+func (call *flight) response(request *http.Request) (*http.Response, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.entry.response(request), nil
+}
+
+func readEntry(response *http.Response, err error) (entry *CacheEntry, _ error) {
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var body []byte
+	if body, err = ioutil.ReadAll(response.Body); err == nil {
+		entry = &CacheEntry{StatusCode: response.StatusCode, Header: response.Header, Body: body}
+	}
+	return entry, err
+}
+
+// method, final url and sorted headers
+func flightKey(request *http.Request) string {
+	key := new(strings.Builder)
+	key.WriteString(request.Method + " " + request.URL.String())
+	names := make([]string, 0, len(request.Header))
+	for name := range request.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(request.Header[name], ", "))
+	}
+	return key.String()
+}
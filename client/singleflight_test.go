@@ -0,0 +1,36 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleflight_Do(t *testing.T) {
+	group := NewSingleflight()
+	var calls int32
+	do := func(request *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader("item"))}, nil
+	}
+	var wait sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			request, _ := http.NewRequest(http.MethodGet, "http://example.com/item", nil)
+			response, err := group.Do(request, do)
+			assert.Nil(t, err)
+			body, _ := ioutil.ReadAll(response.Body)
+			assert.Equal(t, "item", string(body))
+		}()
+	}
+	wait.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,111 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"github.com/rady-io/http-service/headers"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	Redacted = "[REDACTED]"
+)
+
+var (
+	// headers always redacted by WithLogger
+	DefaultRedactedHeaders = []string{headers.HeaderAuthorization, headers.HeaderCookie, "Set-Cookie", "Proxy-Authorization"}
+)
+
+type (
+	LogOption func(logger *slogLogger)
+
+	slogLogger struct {
+		logger       *slog.Logger
+		redacted     map[string]bool
+		previewLimit int
+	}
+)
+
+// WithLogger logs every call to logger: successful calls at debug level, failed calls at warn level
+func WithLogger(logger *slog.Logger, options ...LogOption) Option {
+	callLogger := &slogLogger{logger: logger, redacted: make(map[string]bool), previewLimit: DefaultPreviewLimit}
+	RedactHeaders(DefaultRedactedHeaders...)(callLogger)
+	for _, option := range options {
+		option(callLogger)
+	}
+	return WithCallLogger(callLogger, callLogger.previewLimit)
+}
+
+// RedactHeaders hides values of headers with keys, in addition to DefaultRedactedHeaders
+func RedactHeaders(keys ...string) LogOption {
+	return func(logger *slogLogger) {
+		for _, key := range keys {
+			logger.redacted[http.CanonicalHeaderKey(key)] = true
+		}
+	}
+}
+
+// PreviewLimit keeps limit bytes of bodies; 0 logs no body
+func PreviewLimit(limit int) LogOption {
+	return func(logger *slogLogger) {
+		logger.previewLimit = limit
+	}
+}
+
+func (logger *slogLogger) LogCall(call *Call, record *CallRecord) {
+	level := slog.LevelDebug
+	if record.Err != nil {
+		level = slog.LevelWarn
+	}
+	ctx := record.Request.Context()
+	if !logger.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("service", call.Service),
+		slog.String("method", call.Method),
+		slog.String("route", call.Route),
+		slog.String("http_method", record.Request.Method),
+		slog.String("url", record.Request.URL.Redacted()),
+		slog.Any("request_header", logger.header(record.Request.Header)),
+	}
+	if len(record.RequestBody) != 0 {
+		attrs = append(attrs, slog.String("request_body", string(record.RequestBody)))
+	}
+	if record.Response != nil {
+		attrs = append(attrs,
+			slog.Int("status", record.Response.StatusCode),
+			slog.Any("response_header", logger.header(record.Response.Header)),
+		)
+		if len(record.ResponseBody) != 0 {
+			attrs = append(attrs, slog.String("response_body", string(record.ResponseBody)))
+		}
+	}
+	attrs = append(attrs, slog.Duration("duration", record.Duration))
+	if record.Err != nil {
+		attrs = append(attrs, slog.String("error", record.Err.Error()))
+	}
+	logger.logger.LogAttrs(ctx, level, "http call", attrs...)
+}
+
+// header as a group of comma-joined values, with redacted values hidden
+func (logger *slogLogger) header(header http.Header) slog.Value {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		value := strings.Join(header[key], ", ")
+		if logger.redacted[http.CanonicalHeaderKey(key)] {
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
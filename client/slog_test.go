@@ -0,0 +1,45 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	output := new(bytes.Buffer)
+	logger := slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	config := NewConfig(WithLogger(logger, RedactHeaders("X-Api-Key"), PreviewLimit(4)))
+	call := &Call{Service: "Service", Method: "UpdateItem", HTTPMethod: http.MethodPut, Route: "/item/{id}"}
+	request, err := http.NewRequest(http.MethodPut, "http://box.zjuqsc.com/item/1", strings.NewReader(`{"name":"box"}`))
+	assert.Nil(t, err)
+	request.Header.Set("Authorization", "Bearer token")
+	request.Header.Set("X-Api-Key", "key")
+	request.Header.Set("Accept", "application/json")
+	response := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: ioutil.NopCloser(strings.NewReader("content"))}
+
+	config.Log(call, request, time.Now(), response, nil)
+	assert.Zero(t, output.Len())
+	_, err = ioutil.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Nil(t, response.Body.Close())
+
+	line := output.String()
+	assert.Contains(t, line, "level=DEBUG")
+	assert.Contains(t, line, "method=UpdateItem")
+	assert.Contains(t, line, "request_header.Authorization=[REDACTED]")
+	assert.Contains(t, line, "request_header.X-Api-Key=[REDACTED]")
+	assert.Contains(t, line, "request_header.Accept=application/json")
+	assert.Contains(t, line, `request_body="{\"na"`)
+	assert.Contains(t, line, "response_body=cont")
+	assert.Contains(t, line, "status=200")
+	assert.NotContains(t, line, "Bearer")
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	HeaderTraceParent = "traceparent"
+)
+
+type (
+	// Call describes a generated method, for tracing and metrics
+	Call struct {
+		Service    string
+		Method     string
+		HTTPMethod string
+		Route      string // path template of the method. etc. /item/{id}
+	}
+
+	// Tracer starts a span for every outbound call; an OpenTelemetry adapter
+	// starts a client span in the request context and injects it into the request headers
+	Tracer interface {
+		Start(request *http.Request, call *Call) (*http.Request, Span)
+	}
+
+	// Span ends when the response arrives or the call fails
+	Span interface {
+		End(response *http.Response, err error)
+	}
+
+	noopTracer struct{}
+	noopSpan   struct{}
+)
+
+// WithTracer traces calls by tracer instead of doing nothing
+func WithTracer(tracer Tracer) Option {
+	return func(config *Config) {
+		config.tracer = tracer
+	}
+}
+
+func (noopTracer) Start(request *http.Request, call *Call) (*http.Request, Span) {
+	return request, noopSpan{}
+}
+
+func (noopSpan) End(response *http.Response, err error) {}
+
+// SetTraceParent sets the W3C trace-context header, for tracers without a propagator
+func SetTraceParent(header http.Header, traceID [16]byte, spanID [8]byte, sampled bool) {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	header.Set(HeaderTraceParent, "00-"+hex.EncodeToString(traceID[:])+"-"+hex.EncodeToString(spanID[:])+"-"+flags)
+}
@@ -0,0 +1,13 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestSetTraceParent(t *testing.T) {
+	header := make(http.Header)
+	SetTraceParent(header, [16]byte{0x4b, 0xf9, 0x2f}, [8]byte{0x00, 0xf0, 0x67}, true)
+	assert.Equal(t, "00-4bf92f00000000000000000000000000-00f0670000000000-01", header.Get(HeaderTraceParent))
+}
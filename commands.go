@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"github.com/rady-io/http-service/impl"
+	"io/ioutil"
+	"os"
+)
+
+func setupGen(options *Options) func(source *Source) error {
+	output := addOutputFlags(options, DefaultNameTemplate)
+	return func(source *Source) (err error) {
+		var fileName, implPkgPath, implPkgName, code string
+		fileName, implPkgPath, implPkgName, err = output.resolve(source, options.typ)
+		if err == nil {
+			code, err = impl.ImplTo(source.service(options.typ), source.pkgPath, implPkgPath, implPkgName)
+		}
+		if err == nil {
+			err = write(fileName, code)
+		}
+		return
+	}
+}
+
+func setupCheck(options *Options) func(source *Source) error {
+	return func(source *Source) (err error) {
+		if err = source.check(); err == nil {
+			_, err = impl.Impl(source.service(options.typ), source.pkgPath)
+		}
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%s: ok\n", options.typ)
+		}
+		return
+	}
+}
+
+func setupOpenAPI(options *Options) func(source *Source) error {
+	var fileName string
+	options.StringVar(&fileName, "o", ZeroStr, "output file; stdout by default")
+	return func(source *Source) (err error) {
+		var doc []byte
+		if err = source.check(); err == nil {
+			doc, err = impl.OpenAPI(source.service(options.typ), source.pkgPath)
+		}
+		if err == nil {
+			doc = append(doc, '\n')
+			if fileName == ZeroStr {
+				_, err = os.Stdout.Write(doc)
+			} else {
+				err = ioutil.WriteFile(fileName, doc, 0644)
+			}
+		}
+		return
+	}
+}
+
+func setupMock(options *Options) func(source *Source) error {
+	output := addOutputFlags(options, MockNameTemplate)
+	return func(source *Source) (err error) {
+		var fileName, implPkgPath, implPkgName, code string
+		fileName, implPkgPath, implPkgName, err = output.resolve(source, options.typ)
+		if err == nil {
+			code, err = impl.Mock(source.service(options.typ), source.pkgPath, implPkgPath, implPkgName)
+		}
+		if err == nil {
+			err = write(fileName, code)
+		}
+		return
+	}
+}
+
+// diagnostics in the go vet format; exit with 1 if any
+func setupLint(options *Options) func(source *Source) error {
+	return func(source *Source) (err error) {
+		if err = source.check(); err == nil {
+			diagnostics := impl.Lint(source.service(options.typ), source.pkgPath)
+			for _, diagnostic := range diagnostics {
+				position := source.path
+				if diagnostic.Pos.IsValid() {
+					position = source.fset.Position(diagnostic.Pos).String()
+				}
+				fmt.Fprintf(os.Stderr, "%s: %s\n", position, diagnostic.Message)
+			}
+			if len(diagnostics) != 0 {
+				os.Exit(1)
+			}
+		}
+		return
+	}
+}
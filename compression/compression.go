@@ -0,0 +1,145 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rady-io/http-service/headers"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	Gzip     = "gzip"
+	Deflate  = "deflate"
+	Zstd     = "zstd"
+	Identity = "identity"
+
+	// all encodings decoded by Decompress
+	AcceptEncoding = Gzip + ", " + Deflate + ", " + Zstd
+)
+
+const (
+	UnsupportedEncoding = "content encoding is unsupported"
+)
+
+type (
+	// closes the decoder and the original body
+	decodedBody struct {
+		io.Reader
+		closers []io.Closer
+	}
+)
+
+func UnsupportedEncodingError(encoding string) error {
+	return errors.New(UnsupportedEncoding + ": " + encoding)
+}
+
+func IsSupported(encoding string) bool {
+	return encoding == Gzip || encoding == Deflate || encoding == Zstd
+}
+
+// Compress the whole body with encoding; a nil body stays nil
+func Compress(encoding string, body io.Reader) (compressed *bytes.Buffer, err error) {
+	if body == nil {
+		return
+	}
+	compressed = new(bytes.Buffer)
+	var writer io.WriteCloser
+	switch encoding {
+	case Gzip:
+		writer = gzip.NewWriter(compressed)
+	case Deflate:
+		writer = zlib.NewWriter(compressed)
+	case Zstd:
+		writer, err = zstd.NewWriter(compressed)
+	default:
+		err = UnsupportedEncodingError(encoding)
+	}
+	if err == nil {
+		_, err = io.Copy(writer, body)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		compressed = nil
+	}
+	return
+}
+
+// Decompress replaces the body of response by its decoded content, in reverse order of Content-Encoding;
+// the header is removed when the body is decoded
+func Decompress(response *http.Response) (err error) {
+	encodings := Encodings(response.Header)
+	if len(encodings) == 0 {
+		return
+	}
+	var body io.ReadCloser
+	if body, err = Decode(response.Body, encodings); err == nil {
+		response.Body = body
+		response.Header.Del(headers.HeaderContentEncoding)
+		response.Header.Del(headers.HeaderContentLength)
+		response.ContentLength = -1
+		response.Uncompressed = true
+	}
+	return
+}
+
+// Encodings of Content-Encoding in header, except identity
+func Encodings(header http.Header) (encodings []string) {
+	encodings = make([]string, 0)
+	for _, value := range header.Values(headers.HeaderContentEncoding) {
+		for _, encoding := range strings.Split(value, ",") {
+			if encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding != "" && encoding != Identity {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+	return
+}
+
+// Decode body encoded by encodings in order, i.e. decoders are applied in reverse order
+func Decode(encoded io.ReadCloser, encodings []string) (decoded io.ReadCloser, err error) {
+	body := &decodedBody{Reader: encoded, closers: []io.Closer{encoded}}
+	for i := len(encodings) - 1; i >= 0 && err == nil; i-- {
+		switch encodings[i] {
+		case Gzip, "x-gzip":
+			var reader *gzip.Reader
+			if reader, err = gzip.NewReader(body.Reader); err == nil {
+				body.Reader = reader
+				body.closers = append(body.closers, reader)
+			}
+		case Deflate:
+			var reader io.ReadCloser
+			if reader, err = zlib.NewReader(body.Reader); err == nil {
+				body.Reader = reader
+				body.closers = append(body.closers, reader)
+			}
+		case Zstd:
+			var decoder *zstd.Decoder
+			if decoder, err = zstd.NewReader(body.Reader); err == nil {
+				body.Reader = decoder
+				body.closers = append(body.closers, decoder.IOReadCloser())
+			}
+		default:
+			err = UnsupportedEncodingError(encodings[i])
+		}
+	}
+	if err == nil {
+		decoded = body
+	}
+	return
+}
+
+func (body *decodedBody) Close() (err error) {
+	for i := len(body.closers) - 1; i >= 0; i-- {
+		if closeErr := body.closers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
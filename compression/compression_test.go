@@ -0,0 +1,38 @@
+package compression
+
+import (
+	"bytes"
+	"github.com/rady-io/http-service/headers"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompressAndDecompress(t *testing.T) {
+	for _, encoding := range []string{Gzip, Deflate, Zstd} {
+		compressed, err := Compress(encoding, strings.NewReader("hello, impler"))
+		assert.Nil(t, err)
+		response := &http.Response{
+			Header: http.Header{headers.HeaderContentEncoding: []string{encoding}},
+			Body:   ioutil.NopCloser(bytes.NewReader(compressed.Bytes())),
+		}
+		assert.Nil(t, Decompress(response))
+		data, err := ioutil.ReadAll(response.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "hello, impler", string(data))
+		assert.Nil(t, response.Body.Close())
+		assert.Empty(t, response.Header.Get(headers.HeaderContentEncoding))
+	}
+}
+
+func TestDecompressUnsupported(t *testing.T) {
+	response := &http.Response{
+		Header: http.Header{headers.HeaderContentEncoding: []string{"br"}},
+		Body:   ioutil.NopCloser(strings.NewReader("")),
+	}
+	assert.Error(t, Decompress(response))
+	_, err := Compress("br", strings.NewReader(""))
+	assert.Error(t, err)
+}
@@ -1,8 +1,17 @@
 module github.com/rady-io/http-service
 
+go 1.18
+
 require (
 	github.com/dave/jennifer v1.1.0
+	github.com/klauspost/compress v1.13.6
 	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7
 	github.com/rady-io/annotation-processor v1.0.0-alpha
 	github.com/stretchr/testify v1.2.2
+	golang.org/x/tools v0.12.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 )
@@ -2,25 +2,41 @@ package impl
 
 const (
 	// <Annotation Val> first annotations. etc. @Get /item/{id} | @Get
-	GetAnn        = "@Get" // path
-	HeadAnn       = "@Head"
-	PostAnn       = "@Post"
-	PutAnn        = "@Put"
-	PatchAnn      = "@Patch"
-	DeleteAnn     = "@Delete"
-	ConnectAnn    = "@Connect"
-	OptionsAnn    = "@Options"
-	TraceAnn      = "@Trace"
-	BodyAnn       = "@Body"       // json | xml | form | multipart; default json
-	SingleBodyAnn = "@SingleBody" // json | xml | form | multipart; default json; if singleBody, the type of single body var must be IOReader or Other
-	ResultAnn     = "@Result"     // json | xml ; default json
-	BaseAnn       = "@Base"
+	GetAnn            = "@Get" // path
+	HeadAnn           = "@Head"
+	PostAnn           = "@Post"
+	PutAnn            = "@Put"
+	PatchAnn          = "@Patch"
+	DeleteAnn         = "@Delete"
+	ConnectAnn        = "@Connect"
+	OptionsAnn        = "@Options"
+	TraceAnn          = "@Trace"
+	BodyAnn           = "@Body"           // json | xml | form | multipart; default json; service or method
+	SingleBodyAnn     = "@SingleBody"     // json | xml | form | multipart; default json; if singleBody, the type of single body var must be IOReader or Other
+	ResultAnn         = "@Result"         // json | xml | html | sse | ndjson; default json; service or method; json, xml selects decoder by Content-Type
+	AcceptAnn         = "@Accept"         // media range; overrides Accept from @Result; service or method
+	BaseAnn           = "@Base"           // url pattern; urls separated by comma are balanced
+	CompressAnn       = "@Compress"       // gzip | deflate | zstd; service or method
+	CircuitBreakerAnn = "@CircuitBreaker" // (failures=5, open=30s); service or method
+	RateLimitAnn      = "@RateLimit"      // 10/s burst=20 fail adaptive; service or method
+	CacheAnn          = "@Cache"          // (ttl=60s); GET or HEAD; service or method
+	SingleflightAnn   = "@Singleflight"   // GET, HEAD or OPTIONS without body; service or method
+	TimeoutAnn        = "@Timeout"        // duration of the call and decoding; results other than *http.Response or streams; service or method
+	RetryAnn          = "@Retry"          // (attempts=3, backoff=100ms); failures are retried on other endpoints; service default for GET, HEAD, OPTIONS, PUT and DELETE
+	LeftParamsAnn     = "@LeftParams"     // query | body | error; unused params of GET, HEAD and DELETE; default query, body if the method declares a body; service or method
 )
 
 const (
 	// <Annotation(Key) Val> second annotations. etc. @Header(Content-Type) multipart/form | @Header(Content-Type)
-	ParamAnn  = "@Param"
-	HeaderAnn = "@Header" // param type: string
-	CookieAnn = "@Cookie" // param type: string
-	FileAnn   = "@File"   // param type: string
-)
\ No newline at end of file
+	ParamAnn   = "@Param"
+	HeaderAnn  = "@Header"  // param type: string
+	CookieAnn  = "@Cookie"  // param type: string; attributes: @Cookie(name, path=/, domain=example.com, secure, httponly, samesite=lax, maxage=3600)
+	HeadersAnn = "@Headers" // {param} of http.Header or map[string]string; merged per call
+	CookiesAnn = "@Cookies" // {param} of []*http.Cookie or map[string]string; merged per call
+	FileAnn    = "@File"    // path pattern | {param} of []byte, io.Reader or *os.File; options: filename={name}, type=image/png, fs={fsys}
+)
+
+const (
+	// marker of a declaration of services; optional
+	HttpServiceAnn = "@HttpService"
+)
@@ -0,0 +1,68 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"go/types"
+	"net/url"
+)
+
+const (
+	// methods generated for every service; the service interface may declare them
+	SetBaseURLMethod  = "SetBaseURL"  // SetBaseURL(rawURL string) error
+	WithBaseURLMethod = "WithBaseURL" // WithBaseURL(rawURL string) (Service, error)
+
+	IdRawURL   = "genRawURL"
+	IdBaseUrl  = "genBaseUrl"
+	IdEndpoint = "genEndpoint"
+)
+
+func (srv *Service) isBaseUrlMethod(method *Method) bool {
+	params, results := method.signature.Params(), method.signature.Results()
+	if params.Len() != 1 || !types.Identical(params.At(0).Type(), types.Typ[types.String]) ||
+		!types.Identical(results.At(results.Len()-1).Type(), GetType(TypeErr)) {
+		return false
+	}
+	if method.Name() == SetBaseURLMethod {
+		return results.Len() == 1
+	}
+	named, ok := results.At(0).Type().(*types.Named)
+	return results.Len() == 2 && ok && named.Obj().Name() == srv.name
+}
+
+// static base urls are validated when generating
+func (srv *Service) checkBaseUrl() (err error) {
+	for _, pattern := range srv.baseUrls {
+		if len(pattern.ids) == 0 {
+			var baseUrl *url.URL
+			baseUrl, err = url.Parse(pattern.pattern)
+			if err == nil && (!baseUrl.IsAbs() || baseUrl.Host == ZeroStr) {
+				err = UnsupportedAnnotationValueError(BaseAnn, pattern.pattern)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+func (srv *Service) genBaseUrlMethods(file *File) {
+	receiver := Id(srv.self).Qual(srv.implPkg, srv.implName)
+	file.Comment(SetBaseURLMethod + " points all calls of the service to rawURL; safe for concurrent use")
+	file.Func().Params(receiver.Clone()).Id(SetBaseURLMethod).Params(Id(IdRawURL).String()).Error().Block(
+		Return(Id(srv.self).Dot(FieldBaseUrl).Dot("Set").Call(Id(IdRawURL))),
+	)
+
+	file.Comment(WithBaseURLMethod + " returns a copy of the service calling rawURL")
+	file.Func().Params(receiver.Clone()).Id(WithBaseURLMethod).Params(Id(IdRawURL).String()).
+		Params(Id(IdResult).Qual(srv.pkg, srv.name), Id(IdError).Error()).
+		Block(
+			Var().Id(IdBaseUrl).Op("*").Qual(ClientPkg, "BaseURL"),
+			List(Id(IdBaseUrl), Id(IdError)).Op("=").Id(srv.self).Dot(FieldConfig).Dot("ParseBaseURL").Call(Id(IdRawURL)),
+			If(Id(IdError).Op("==").Nil()).Block(
+				Id(srv.self).Dot(FieldBaseUrl).Op("=").Id(IdBaseUrl),
+				Id(IdResult).Op("=").Op("&").Id(srv.self),
+			),
+			Return(),
+		)
+}
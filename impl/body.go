@@ -1,5 +1,10 @@
 package impl
 
+import (
+	"github.com/rady-io/http-service/headers"
+	"strings"
+)
+
 const (
 	// only for response
 
@@ -11,6 +16,12 @@ const (
 
 	// resp, statusCode, error
 	HTML = "html"
+
+	// <-chan item, <-chan error, error
+	SSE = "sse"
+
+	// <-chan item, <-chan error, error
+	NDJSON = "ndjson"
 )
 
 const (
@@ -36,3 +47,61 @@ const (
 type (
 	BodyType string
 )
+
+// media types of a result type, sent in Accept and matched with Content-Type of response
+func (typ BodyType) mediaTypes() []string {
+	switch typ {
+	case JSON:
+		return []string{headers.MIMEApplicationJSON}
+	case XML:
+		return []string{headers.MIMEApplicationXML, headers.MIMETextXML}
+	case HTML:
+		return []string{headers.MIMETextHTML}
+	case SSE:
+		return []string{headers.MIMETextEventStream}
+	case NDJSON:
+		return []string{headers.MIMEApplicationNDJSON}
+	}
+	return []string{}
+}
+
+// media type of a request type, without parameters
+func (typ BodyType) contentType() string {
+	switch typ {
+	case XML:
+		return headers.MIMEApplicationXML
+	case Form:
+		return headers.MIMEApplicationForm
+	case Multipart:
+		return headers.MIMEMultipartForm
+	}
+	return headers.MIMEApplicationJSON
+}
+
+// @Result json | @Result json, xml; stream types cannot be combined with others
+func parseResultTypes(value string) (resultTypes []BodyType, err error) {
+	resultTypes = make([]BodyType, 0)
+	declared := make(map[BodyType]bool)
+	for _, item := range strings.Split(value, ",") {
+		typ := BodyType(strings.TrimSpace(item))
+		if typ != JSON && typ != XML && typ != HTML && typ != SSE && typ != NDJSON || declared[typ] {
+			err = UnsupportedAnnotationValueError(ResultAnn, value)
+			break
+		}
+		declared[typ] = true
+		resultTypes = append(resultTypes, typ)
+	}
+	if err == nil && len(resultTypes) > 1 && (declared[SSE] || declared[NDJSON]) {
+		err = UnsupportedAnnotationValueError(ResultAnn, value)
+	}
+	return
+}
+
+// value of Accept for result types
+func acceptOf(resultTypes []BodyType) string {
+	mediaTypes := make([]string, 0)
+	for _, typ := range resultTypes {
+		mediaTypes = append(mediaTypes, typ.mediaTypes()...)
+	}
+	return strings.Join(mediaTypes, ", ")
+}
@@ -0,0 +1,127 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// options of @CircuitBreaker. etc. @CircuitBreaker(failures=5, open=30s)
+	FailuresOption = "failures"
+	OpenOption     = "open"
+
+	DefaultBreakerFailures = 5
+	DefaultBreakerOpen     = 30 * time.Second
+
+	BreakersMethod = "Breakers" // Breakers() map[string]*client.Breaker
+	FieldBreakers  = "breakers"
+)
+
+type (
+	BreakerMeta struct {
+		maxFailures int
+		open        time.Duration
+	}
+)
+
+// options in key, or in value if the annotation has no key
+func parseBreaker(key, value string) (breaker *BreakerMeta, err error) {
+	breaker = &BreakerMeta{maxFailures: DefaultBreakerFailures, open: DefaultBreakerOpen}
+	options := key
+	if options == ZeroStr {
+		options = value
+	}
+	for _, option := range strings.Split(options, ",") {
+		option = strings.TrimSpace(option)
+		if option == ZeroStr {
+			continue
+		}
+		equal := strings.Index(option, "=")
+		if equal == -1 {
+			err = UnsupportedAnnotationValueError(CircuitBreakerAnn, option)
+			break
+		}
+		optionKey, optionValue := strings.TrimSpace(option[:equal]), strings.TrimSpace(option[equal+1:])
+		switch optionKey {
+		case FailuresOption:
+			breaker.maxFailures, err = strconv.Atoi(optionValue)
+		case OpenOption:
+			breaker.open, err = time.ParseDuration(optionValue)
+		default:
+			err = UnsupportedAnnotationValueError(CircuitBreakerAnn, option)
+		}
+		if err != nil || breaker.maxFailures <= 0 || breaker.open <= 0 {
+			err = UnsupportedAnnotationValueError(CircuitBreakerAnn, option)
+			break
+		}
+	}
+	if err != nil {
+		breaker = nil
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetBreaker(key, value string) (err error) {
+	if meta.breaker != nil {
+		err = DuplicatedAnnotationError(CircuitBreakerAnn)
+	}
+	if err == nil {
+		meta.breaker, err = parseBreaker(key, value)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetBreaker(key, value string) (err error) {
+	if meta.breaker != nil {
+		err = DuplicatedAnnotationError(CircuitBreakerAnn)
+	}
+	if err == nil {
+		meta.breaker, err = parseBreaker(key, value)
+	}
+	return
+}
+
+// the service breaker is keyed by the service name, and a method breaker by the method name
+func (srv *Service) genBreakers(group *Group) {
+	newBreaker := func(name string, breaker *BreakerMeta) {
+		group.Id(srv.self).Dot(FieldBreakers).Index(Lit(name)).Op("=").Qual(ClientPkg, "NewBreaker").
+			Call(Lit(name), Lit(breaker.maxFailures), durationCode(breaker.open))
+	}
+	if srv.breaker != nil {
+		newBreaker(srv.name, srv.breaker)
+	}
+	for _, method := range srv.sortedMethods() {
+		if method.breaker != nil {
+			newBreaker(method.Name(), method.breaker)
+		}
+	}
+}
+
+func (srv *Service) genBreakersMethod(file *File) {
+	file.Comment(BreakersMethod + " by service or method name, for health checks")
+	file.Func().Params(Id(srv.self).Qual(srv.implPkg, srv.implName)).Id(BreakersMethod).Params().
+		Map(String()).Op("*").Qual(ClientPkg, "Breaker").
+		Block(Return(Id(srv.self).Dot(FieldBreakers)))
+}
+
+func (srv *Service) isBreakersMethod(method *Method) bool {
+	params, results := method.signature.Params(), method.signature.Results()
+	return params.Len() == 0 && results.Len() == 1 &&
+		results.At(0).Type().String() == "map[string]*"+ClientPkg+".Breaker"
+}
+
+// a call is rejected by an open breaker before making the request
+func (method *Method) allowBreaker(group *Group) {
+	if method.breakerKey != ZeroStr {
+		group.Id(IdError).Op("=").Id(method.service.self).Dot(FieldBreakers).Index(Lit(method.breakerKey)).Dot("Allow").Call()
+		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+	}
+}
+
+func (method *Method) reportBreaker(group *Group) {
+	if method.breakerKey != ZeroStr {
+		group.Id(method.service.self).Dot(FieldBreakers).Index(Lit(method.breakerKey)).Dot("Report").Call(Id(IdResponse), Id(IdError))
+	}
+}
@@ -0,0 +1,87 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// options of @Cache. etc. @Cache(ttl=60s)
+	TTLOption = "ttl"
+)
+
+type (
+	CacheMeta struct {
+		ttl time.Duration // freshness of responses without max-age or Expires
+	}
+)
+
+// options in key, or in value if the annotation has no key; ttl defaults to 0, revalidating every call
+func parseCache(key, value string) (cache *CacheMeta, err error) {
+	cache = new(CacheMeta)
+	options := key
+	if options == ZeroStr {
+		options = value
+	}
+	for _, option := range strings.Split(options, ",") {
+		option = strings.TrimSpace(option)
+		if option == ZeroStr {
+			continue
+		}
+		if strings.HasPrefix(option, TTLOption+"=") {
+			cache.ttl, err = time.ParseDuration(strings.TrimPrefix(option, TTLOption+"="))
+		}
+		if err != nil || !strings.HasPrefix(option, TTLOption+"=") || cache.ttl < 0 {
+			cache, err = nil, UnsupportedAnnotationValueError(CacheAnn, option)
+			break
+		}
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetCache(key, value string) (err error) {
+	if meta.cache != nil {
+		err = DuplicatedAnnotationError(CacheAnn)
+	}
+	if err == nil {
+		meta.cache, err = parseCache(key, value)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetCache(key, value string) (err error) {
+	if meta.cache != nil {
+		err = DuplicatedAnnotationError(CacheAnn)
+	}
+	if err == nil {
+		meta.cache, err = parseCache(key, value)
+	}
+	return
+}
+
+// the service cache applies to cacheable methods only; a method cache must be cacheable
+func (method *Method) resolveCache() (err error) {
+	if method.cache == nil && method.cacheable() {
+		method.cache = method.service.cache
+	} else if method.cache != nil && !method.cacheable() {
+		err = ConflictAnnotationError(CacheAnn, method.signature)
+	}
+	return
+}
+
+// GET or HEAD, whose response is read completely
+func (method *Method) cacheable() bool {
+	return (method.httpMethod == http.MethodGet || method.httpMethod == http.MethodHead) &&
+		method.resultType != HttpRequest && !method.isStream()
+}
+
+// calls the client through the cache, so that a hit neither waits for the limiter nor counts for the breaker
+func (method *Method) genCachedDo(group *Group) {
+	if method.cache == nil {
+		method.genRetriedDo(group)
+	} else {
+		method.wrapDo(group, "Cache", []Code{durationCode(method.cache.ttl)}, method.genRetriedDo)
+	}
+}
@@ -0,0 +1,96 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"strconv"
+	"strings"
+)
+
+const (
+	// attributes of @Cookie. etc. @Cookie(session, path=/, domain=example.com, secure, httponly, samesite=lax, maxage=3600)
+	PathAttr     = "path"
+	DomainAttr   = "domain"
+	SecureAttr   = "secure"
+	HttpOnlyAttr = "httponly"
+	SameSiteAttr = "samesite"
+	MaxAgeAttr   = "maxage"
+)
+
+var (
+	sameSiteModes = map[string]string{
+		"lax":    "SameSiteLaxMode",
+		"strict": "SameSiteStrictMode",
+		"none":   "SameSiteNoneMode",
+	}
+)
+
+type (
+	CookieMeta struct {
+		*PatternMeta
+		attributes Dict // fields of http.Cookie; empty if unscoped
+	}
+)
+
+// name, attributes... -> name and fields of http.Cookie
+func parseCookieKey(key string) (name string, attributes Dict, err error) {
+	items := strings.Split(key, ",")
+	name = strings.TrimSpace(items[0])
+	attributes = Dict{}
+	for _, item := range items[1:] {
+		item = strings.TrimSpace(item)
+		attr, value := item, ZeroStr
+		if equal := strings.Index(item, "="); equal != -1 {
+			attr, value = strings.TrimSpace(item[:equal]), strings.TrimSpace(item[equal+1:])
+		}
+		switch strings.ToLower(attr) {
+		case PathAttr:
+			attributes[Id("Path")] = Lit(value)
+		case DomainAttr:
+			attributes[Id("Domain")] = Lit(value)
+		case SecureAttr:
+			attributes[Id("Secure")] = True()
+		case HttpOnlyAttr:
+			attributes[Id("HttpOnly")] = True()
+		case SameSiteAttr:
+			if mode, ok := sameSiteModes[strings.ToLower(value)]; ok {
+				attributes[Id("SameSite")] = Qual(HttpPkg, mode)
+			} else {
+				err = UnsupportedAnnotationValueError(CookieAnn, item)
+			}
+		case MaxAgeAttr:
+			var maxAge int
+			if maxAge, err = strconv.Atoi(value); err == nil {
+				attributes[Id("MaxAge")] = Lit(maxAge)
+			} else {
+				err = UnsupportedAnnotationValueError(CookieAnn, item)
+			}
+		default:
+			err = UnsupportedAnnotationValueError(CookieAnn, item)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+// &http.Cookie{Name: key, Value: pattern, attributes...}
+func (cookie *CookieMeta) literal() Code {
+	fields := Dict{
+		Id("Name"):  Lit(cookie.key),
+		Id("Value"): cookie.genValue(),
+	}
+	for field, value := range cookie.attributes {
+		fields[field] = value
+	}
+	return Op("&").Qual(HttpPkg, "Cookie").Values(fields)
+}
+
+// cookies with attributes are only sent to matched requests
+func (cookie *CookieMeta) addTo(group *Group, request Code) {
+	if len(cookie.attributes) == 0 {
+		group.Add(request).Dot("AddCookie").Call(cookie.literal())
+	} else {
+		group.Qual(ClientPkg, "AddCookie").Call(request, cookie.literal())
+	}
+}
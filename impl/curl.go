@@ -0,0 +1,26 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"go/types"
+)
+
+const (
+	CurlMethod = "Curl" // Curl(request *http.Request) (string, error)
+
+	IdCurlRequest = "genCurlRequest"
+)
+
+func (srv *Service) genCurlMethod(file *File) {
+	file.Comment(CurlMethod + " renders request, such as one of a method returning *http.Request, as a curl command with the cookies of the cookie jar")
+	file.Func().Params(Id(srv.self).Qual(srv.implPkg, srv.implName)).Id(CurlMethod).
+		Params(Id(IdCurlRequest).Op("*").Qual(HttpPkg, "Request")).Params(String(), Error()).
+		Block(Return(Id(srv.self).Dot(FieldConfig).Dot("Curl").Call(Id(IdCurlRequest))))
+}
+
+func (srv *Service) isCurlMethod(method *Method) bool {
+	params, results := method.signature.Params(), method.signature.Results()
+	return params.Len() == 1 && params.At(0).Type().String() == "*"+HttpPkg+".Request" &&
+		results.Len() == 2 && types.Identical(results.At(0).Type(), types.Typ[types.String]) &&
+		types.Identical(results.At(1).Type(), GetType(TypeErr))
+}
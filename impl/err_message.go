@@ -3,17 +3,31 @@ package impl
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
-	DuplicatedAnnotation           = "duplicated annotation"
-	DuplicatedHttpMethod           = "duplicated http method"
-	IdNotExist                     = "id does not exist"
-	PatternIdTypeMustBeIntOrString = "id in pattern must be int or string"
-	PatternKeyMustNotBeEmpty       = "key of pattern must not be empty"
-	SingleBodyWithMultiBodyVars    = "singleBody with multi body vars"
-	ConflictAnnotation             = "annotation conflict"
-	UnsupportedAnnotationValue     = "annotation value is unsupported"
+	DuplicatedAnnotation        = "duplicated annotation"
+	DuplicatedHttpMethod        = "duplicated http method"
+	IdNotExist                  = "id does not exist"
+	PatternIdTypeUnsupported    = "id in pattern must be a basic type, time.Time, time.Duration, fmt.Stringer or encoding.TextMarshaler"
+	PatternFormatUnsupported    = "format of id in pattern is unsupported"
+	PatternVerbMismatch         = "printf verb does not fit the type of id in pattern"
+	PatternKeyMustNotBeEmpty    = "key of pattern must not be empty"
+	SingleBodyWithMultiBodyVars = "singleBody with multi body vars"
+	ConflictAnnotation          = "annotation conflict"
+	UnsupportedAnnotationValue  = "annotation value is unsupported"
+	PathFieldUnderPointer       = "path field must not be under a nested pointer"
+	DuplicatedPathId            = "duplicated path id"
+	FormEncodingUnsupported     = "type cannot be form-encoded"
+	FileSourceUnsupported       = "file source must be []byte, io.Reader, *os.File or a path"
+	FSMustBeFS                  = "fs option must be a param of fs.FS"
+	HeadersTypeUnsupported      = "param of @Headers must be http.Header or map[string]string"
+	CookiesTypeUnsupported      = "param of @Cookies must be []*http.Cookie or map[string]string"
+	ReservedMethod              = "method is generated for every service, its signature mismatches"
+	ServiceNotFound             = "service is not an interface type in the file"
+	LeftParamsWithoutBody       = "params are not used by any annotation of a method without body"
+	StreamWithoutContext        = "method with a stream result must have a context.Context param"
 )
 
 func DuplicatedAnnotationError(ann string) error {
@@ -28,8 +42,16 @@ func IdNotExistError(id string) error {
 	return errors.New(IdNotExist + ": " + id)
 }
 
-func PatternIdTypeMustBeIntOrStringError(id string) error {
-	return errors.New(PatternIdTypeMustBeIntOrString + ": " + id)
+func PatternIdTypeUnsupportedError(id string) error {
+	return errors.New(PatternIdTypeUnsupported + ": " + id)
+}
+
+func PatternFormatUnsupportedError(id, format string) error {
+	return errors.New(PatternFormatUnsupported + fmt.Sprintf(": {%s:%s}", id, format))
+}
+
+func PatternVerbMismatchError(id, format, typ string) error {
+	return errors.New(PatternVerbMismatch + fmt.Sprintf(": {%s:%s} of %s", id, format, typ))
 }
 
 func UnsupportedAnnotationValueError(ann, value string) error {
@@ -39,3 +61,48 @@ func UnsupportedAnnotationValueError(ann, value string) error {
 func ConflictAnnotationError(ann string, value fmt.Stringer) error {
 	return errors.New(ConflictAnnotation + fmt.Sprintf(": %s <!> %s", ann, value))
 }
+
+func PathFieldUnderPointerError(expr string) error {
+	return errors.New(PathFieldUnderPointer + ": " + expr)
+}
+
+func DuplicatedPathIdError(id string) error {
+	return errors.New(DuplicatedPathId + ": " + id)
+}
+
+func FormEncodingUnsupportedError(typ string) error {
+	return errors.New(FormEncodingUnsupported + ": " + typ)
+}
+
+func FileSourceUnsupportedError(id string) error {
+	return errors.New(FileSourceUnsupported + ": " + id)
+}
+
+func FSMustBeFSError(id string) error {
+	return errors.New(FSMustBeFS + ": " + id)
+}
+
+func HeadersTypeUnsupportedError(id string) error {
+	return errors.New(HeadersTypeUnsupported + ": " + id)
+}
+
+func CookiesTypeUnsupportedError(id string) error {
+	return errors.New(CookiesTypeUnsupported + ": " + id)
+}
+
+func ReservedMethodError(method string) error {
+	return errors.New(ReservedMethod + ": " + method)
+}
+
+func ServiceNotFoundError(service string) error {
+	return errors.New(ServiceNotFound + ": " + service)
+}
+
+func LeftParamsWithoutBodyError(params []string) error {
+	return errors.New(LeftParamsWithoutBody + ": " + strings.Join(params, ", "))
+}
+
+// error of a method, with the service and method name
+func MethodError(service, method string, err error) error {
+	return errors.New(fmt.Sprintf("%s.%s: %s", service, method, err))
+}
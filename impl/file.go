@@ -0,0 +1,191 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"github.com/rady-io/http-service/headers"
+	. "github.com/rady-io/http-service/log"
+	"strings"
+)
+
+const (
+	// options of @File. etc. @File(avatar, filename={name}, type=image/png)
+	FilenameOption = "filename"
+	TypeOption     = "type"
+	FSOption       = "fs"
+)
+
+const (
+	// sources of a file part
+	SourcePath = iota
+	SourceFS
+	SourceBytes
+	SourceReader
+	SourceOSFile
+)
+
+type (
+	FileSource int
+
+	FileMeta struct {
+		source      FileSource
+		fsId        string       // param of fs.FS, only for SourceFS
+		filename    *PatternMeta // nil: base of the path, name of *os.File or the key
+		contentType *PatternMeta // nil: application/octet-stream
+	}
+)
+
+// @File(key, options...) /path/{pattern} | @File(key, options...) {param}
+func (meta *MethodMeta) TryAddFile(key, value string) (err error) {
+	options := strings.Split(key, ",")
+	key = strings.TrimSpace(options[0])
+	file := &FileMeta{source: SourcePath}
+	for _, option := range options[1:] {
+		option = strings.TrimSpace(option)
+		equal := strings.Index(option, "=")
+		if equal == -1 {
+			err = UnsupportedAnnotationValueError(FileAnn, option)
+			break
+		}
+		optionKey, optionValue := strings.TrimSpace(option[:equal]), strings.TrimSpace(option[equal+1:])
+		switch optionKey {
+		case FilenameOption:
+			file.filename, err = meta.genPatternMeta(FilenameOption, optionValue)
+		case TypeOption:
+			file.contentType, err = meta.genPatternMeta(TypeOption, optionValue)
+		case FSOption:
+			err = meta.setFS(file, optionValue)
+		default:
+			err = UnsupportedAnnotationValueError(FileAnn, option)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		var patternMeta *PatternMeta
+		if id, ok := meta.getSourceId(value); ok && file.source != SourceFS {
+			patternMeta = &PatternMeta{key: key, ids: []string{meta.totalIds[id].key}}
+			file.source, err = getFileSource(meta.totalIds[id])
+			meta.idList.deleteKey(id)
+		} else {
+			patternMeta, err = meta.genPatternMeta(key, value)
+		}
+		if err == nil {
+			Log.Debugf("Set File(%s) %s", key, value)
+			meta.bodyVars = append(meta.bodyVars, &BodyMeta{PatternMeta: patternMeta, typ: TypeFile, file: file})
+		}
+	}
+	return
+}
+
+// value is a single {param} which is not int or string
+func (meta *MethodMeta) getSourceId(value string) (id string, ok bool) {
+	if IdRe.FindString(value) == value && value != ZeroStr {
+		id = getIdFromPattern(value)
+		if paramMeta, exist := meta.totalIds[id]; exist {
+			ok = paramMeta.typ != TypeInt && paramMeta.typ != TypeString
+		}
+	}
+	return
+}
+
+func getFileSource(paramMeta *ParamMeta) (source FileSource, err error) {
+	switch {
+	case paramMeta.typ == IOReader:
+		source = SourceReader
+	case paramMeta.goType.String() == GetType(TypeBytes).String():
+		source = SourceBytes
+	case paramMeta.goType.String() == GetType(TypeOSFile).String():
+		source = SourceOSFile
+	default:
+		err = FileSourceUnsupportedError(paramMeta.key)
+	}
+	return
+}
+
+func (meta *MethodMeta) setFS(file *FileMeta, value string) (err error) {
+	id, ok := meta.getSourceId(value)
+	if !ok || meta.totalIds[id].goType.String() != GetType(TypeFS).String() {
+		err = FSMustBeFSError(value)
+	}
+	if err == nil {
+		file.source = SourceFS
+		file.fsId = meta.totalIds[id].key
+		meta.idList.deleteKey(id)
+	}
+	return
+}
+
+// render the value of a pattern: literal, single string id or fmt.Sprintf
+func (pattern *PatternMeta) genValue() Code {
+	if len(pattern.ids) == 0 {
+		return Lit(pattern.pattern)
+	} else if pattern.pattern == StringPlaceholder {
+		return Id(pattern.ids[0])
+	}
+	return Qual(FormatPkg, "Sprintf").Call(Lit(pattern.pattern), List(genIds(pattern.ids)...))
+}
+
+// a part with Content-Disposition and Content-Type headers
+func (method *Method) getFileWriter(bodyVar *BodyMeta) func(group *Group) {
+	file := bodyVar.file
+	return func(group *Group) {
+		group.Var().Id(IdPartWriter).Qual(IO, "Writer")
+		var source Code
+		switch file.source {
+		case SourcePath, SourceFS:
+			group.Var().Id(IdFilePath).String()
+			group.Id(IdFilePath).Op("=").Add(bodyVar.genValue())
+			if file.source == SourcePath {
+				group.Var().Id(IdFile).Op("*").Qual(OS, "File")
+				group.List(Id(IdFile), Id(IdError)).Op("=").Qual(OS, "Open").Call(Id(IdFilePath))
+			} else {
+				group.Var().Id(IdFile).Qual(FSPkg, "File")
+				group.List(Id(IdFile), Id(IdError)).Op("=").Id(file.fsId).Dot("Open").Call(Id(IdFilePath))
+			}
+			group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+			group.Defer().Id(IdFile).Dot("Close").Call()
+			source = Id(IdFile)
+		default:
+			source = Id(bodyVar.ids[0])
+		}
+
+		var filename Code
+		switch {
+		case file.filename != nil:
+			filename = file.filename.genValue()
+		case file.source == SourcePath:
+			filename = Qual(FilepathPkg, "Base").Call(Id(IdFilePath))
+		case file.source == SourceFS:
+			filename = Qual(PathPkg, "Base").Call(Id(IdFilePath))
+		case file.source == SourceOSFile:
+			filename = Qual(FilepathPkg, "Base").Call(Id(bodyVar.ids[0]).Dot("Name").Call())
+		default:
+			filename = Lit(bodyVar.key)
+		}
+		var contentType Code = Lit(headers.MIMEOctetStream)
+		if file.contentType != nil {
+			contentType = file.contentType.genValue()
+		}
+
+		group.Id(IdPartHeader).Op(":=").Make(Qual(Textproto, "MIMEHeader"))
+		group.Id(IdPartHeader).Dot("Set").Call(
+			Lit(headers.HeaderContentDisposition),
+			Qual(MIMEPkg, "FormatMediaType").Call(Lit("form-data"), Map(String()).String().Values(Dict{
+				Lit("name"):     Lit(bodyVar.key),
+				Lit("filename"): filename,
+			})),
+		)
+		group.Id(IdPartHeader).Dot("Set").Call(Lit(headers.HeaderContentType), contentType)
+		group.List(Id(IdPartWriter), Id(IdError)).Op("=").Id(IdBodyWriter).Dot("CreatePart").Call(Id(IdPartHeader))
+		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+
+		if file.source == SourceBytes {
+			group.List(Id("_"), Id(IdError)).Op("=").Id(IdPartWriter).Dot("Write").Call(source)
+		} else {
+			group.List(Id("_"), Id(IdError)).Op("=").Qual(IO, "Copy").Call(Id(IdPartWriter), source)
+		}
+		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+	}
+}
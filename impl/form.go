@@ -0,0 +1,167 @@
+package impl
+
+import (
+	"fmt"
+	. "github.com/dave/jennifer/jen"
+	"go/types"
+	"reflect"
+)
+
+type (
+	// add a key-value pair to form, query, header or cookies
+	FormAdder func(key Code, value Code) *Statement
+)
+
+// encode value as form fields: encoding.TextMarshalers are marshaled, scalars and fmt.Stringers are formatted,
+// nil pointers are skipped, slices are repeated keys, maps with string keys are spread into fields
+// and structs are encoded field by field using form tags
+func encodeFormValue(key Code, value *Statement, typ types.Type, add FormAdder) (statements []Code, err error) {
+	return newFormEncoder(add).encode(key, value, typ, 0)
+}
+
+type formEncoder struct {
+	add     FormAdder
+	visited map[*types.Struct]bool
+}
+
+func newFormEncoder(add FormAdder) *formEncoder {
+	return &formEncoder{add: add, visited: make(map[*types.Struct]bool)}
+}
+
+func (encoder *formEncoder) encode(key Code, value *Statement, typ types.Type, depth int) (statements []Code, err error) {
+	statements = make([]Code, 0)
+	if isScalar(typ) && isTextMarshaler(typ) {
+		statements = append(statements, marshalText(key, value, encoder.add))
+	} else if isScalar(typ) {
+		statements = append(statements, encoder.add(key, formatValue(value, typ)))
+	} else {
+		switch underlying := typ.Underlying().(type) {
+		case *types.Pointer:
+			elemValue := Op("*").Add(value.Clone())
+			if _, isStruct := underlying.Elem().Underlying().(*types.Struct); isStruct && !isScalar(underlying.Elem()) {
+				elemValue = value.Clone()
+			}
+			var elemStatements []Code
+			if !isScalar(underlying.Elem()) && isTextMarshaler(typ) {
+				// MarshalText with a pointer receiver
+				elemStatements = []Code{marshalText(key, value.Clone(), encoder.add)}
+			} else {
+				elemStatements, err = encoder.encode(key, elemValue, underlying.Elem(), depth)
+			}
+			if err == nil {
+				statements = append(statements, If(value.Clone().Op("!=").Nil()).Block(elemStatements...))
+			}
+		case *types.Slice:
+			if basic, ok := underlying.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+				statements = append(statements, encoder.add(key, String().Call(value)))
+			} else {
+				statements, err = encoder.encodeRange(key, Id("_"), value, underlying.Elem(), depth)
+			}
+		case *types.Array:
+			statements, err = encoder.encodeRange(key, Id("_"), value, underlying.Elem(), depth)
+		case *types.Map:
+			if basic, ok := underlying.Key().Underlying().(*types.Basic); !ok || basic.Info()&types.IsString == 0 {
+				err = FormEncodingUnsupportedError(typ.String())
+			} else {
+				keyId := Id(fmt.Sprintf("%s%d", IdFormKey, depth))
+				statements, err = encoder.encodeRange(formatValue(keyId.Clone(), underlying.Key()), keyId, value, underlying.Elem(), depth)
+			}
+		case *types.Struct:
+			statements, err = encoder.encodeStruct(value, underlying, depth)
+		default:
+			err = FormEncodingUnsupportedError(typ.String())
+		}
+	}
+	return
+}
+
+// for key, value := range values; elements must be scalars, pointers to scalars or slices of them
+func (encoder *formEncoder) encodeRange(key Code, keyId *Statement, value *Statement, elem types.Type, depth int) (statements []Code, err error) {
+	if !isFlat(elem, true) {
+		err = FormEncodingUnsupportedError(elem.String())
+	}
+	if err == nil {
+		valueId := Id(fmt.Sprintf("%s%d", IdValue, depth))
+		var elemStatements []Code
+		elemStatements, err = encoder.encode(key, valueId.Clone(), elem, depth+1)
+		if err == nil {
+			statements = []Code{For(List(keyId, valueId).Op(":=").Range().Add(value)).Block(elemStatements...)}
+		}
+	}
+	return
+}
+
+func isFlat(typ types.Type, allowSlice bool) bool {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Pointer:
+		return isScalar(underlying.Elem())
+	case *types.Slice:
+		return isScalar(typ) || allowSlice && isFlat(underlying.Elem(), false)
+	}
+	return isScalar(typ)
+}
+
+func (encoder *formEncoder) encodeStruct(value *Statement, structType *types.Struct, depth int) (statements []Code, err error) {
+	statements = make([]Code, 0)
+	if encoder.visited[structType] {
+		err = FormEncodingUnsupportedError("recursive " + structType.String())
+	} else {
+		encoder.visited[structType] = true
+		defer delete(encoder.visited, structType)
+	}
+
+	for i := 0; i < structType.NumFields() && err == nil; i++ {
+		field := structType.Field(i)
+		if !field.Exported() && !field.Embedded() {
+			continue
+		}
+		meta := &FieldMeta{name: field.Name(), tags: reflect.StructTag(structType.Tag(i)), typ: field.Type()}
+		key, omitEmpty, ok := meta.lookup(FormTag)
+		if _, tagged := meta.tags.Lookup(FormTag); tagged && !ok {
+			continue
+		}
+		if !ok {
+			key = field.Name()
+		}
+		fieldValue := value.Clone().Dot(field.Name())
+		var fieldStatements []Code
+		fieldStatements, err = encoder.encode(Lit(key), fieldValue, field.Type(), depth)
+		if err == nil {
+			if omitEmpty {
+				statements = append(statements, If(notEmpty(fieldValue.Clone(), field.Type())).Block(fieldStatements...))
+			} else {
+				statements = append(statements, fieldStatements...)
+			}
+		}
+	}
+	return
+}
+
+// basic types and non-pointer types implementing fmt.Stringer or encoding.TextMarshaler
+func isScalar(typ types.Type) bool {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Basic:
+		return underlying.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0
+	case *types.Pointer:
+		return false
+	}
+	return isTextMarshaler(typ) || isStringer(typ)
+}
+
+func isStringer(typ types.Type) bool {
+	method, _, _ := types.LookupFieldOrMethod(typ, false, nil, "String")
+	if fn, ok := method.(*types.Func); ok {
+		signature := fn.Type().(*types.Signature)
+		if signature.Params().Len() == 0 && signature.Results().Len() == 1 {
+			basic, isBasic := signature.Results().At(0).Type().(*types.Basic)
+			return isBasic && basic.Kind() == types.String
+		}
+	}
+	return false
+}
+
+func addStatements(group *Group, statements []Code) {
+	for _, statement := range statements {
+		group.Add(statement)
+	}
+}
@@ -0,0 +1,45 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"github.com/stretchr/testify/assert"
+	"go/types"
+	"testing"
+)
+
+func TestEncodeFormValue(t *testing.T) {
+	add := func(key Code, value Code) *Statement {
+		return Id("values").Dot("Add").Call(key, value)
+	}
+	str := types.Typ[types.String]
+
+	statements, err := encodeFormValue(Lit("tag"), Id("tags"), types.NewSlice(str), add)
+	assert.Nil(t, err)
+	assert.Equal(t, "for _, genValue0 := range tags {\n\tvalues.Add(\"tag\", genValue0)\n}", Add(statements...).GoString())
+
+	statements, err = encodeFormValue(Lit("extra"), Id("extra"), types.NewMap(str, str), add)
+	assert.Nil(t, err)
+	assert.Equal(t, "for genKey0, genValue0 := range extra {\n\tvalues.Add(genKey0, genValue0)\n}", Add(statements...).GoString())
+
+	statements, err = encodeFormValue(Lit("since"), Id("since"), GetType(TypeTime), add)
+	assert.Nil(t, err)
+	assert.Equal(t, "if genText, genTextErr := client.MarshalText(since); genTextErr == nil {\n\tvalues.Add(\"since\", genText)\n} else {\n\tgenErr = genTextErr\n\treturn\n}", Add(statements...).GoString())
+
+	_, err = encodeFormValue(Lit("ids"), Id("ids"), types.NewMap(types.Typ[types.Int], str), add)
+	assert.Error(t, err)
+
+	_, err = encodeFormValue(Lit("events"), Id("events"), types.NewChan(types.SendRecv, str), add)
+	assert.Error(t, err)
+
+	// an error of multipart fields returns early
+	statements, err = encodeFormValue(Lit("tag"), Id("tags"), types.NewSlice(str), writeField)
+	assert.Nil(t, err)
+	assert.Equal(t, "for _, genValue0 := range tags {\n\tif genErr = genBodyWriter.WriteField(\"tag\", genValue0); genErr != nil {\n\t\treturn\n\t}\n}", Add(statements...).GoString())
+
+	// an encoder error fails the generation
+	method := &Method{MethodMeta: &MethodMeta{bodyVars: []*BodyMeta{{PatternMeta: &PatternMeta{key: "events", ids: []string{"events"}}, typ: Other, goType: types.NewChan(types.SendRecv, str)}}}}
+	BlockFunc(func(group *Group) {
+		assert.Error(t, method.genFormBody(group))
+		assert.Error(t, method.genMultipartBody(group))
+	})
+}
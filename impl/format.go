@@ -0,0 +1,239 @@
+package impl
+
+import (
+	"fmt"
+	. "github.com/dave/jennifer/jen"
+	. "github.com/rady-io/http-service/log"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+const (
+	// formats of time.Time
+	FormatRFC3339     = "rfc3339"
+	FormatRFC3339Nano = "rfc3339nano"
+	FormatHttpTime    = "http" // http.TimeFormat in UTC
+	FormatDate        = "date"
+	FormatUnix        = "unix"
+	FormatUnixMilli   = "unixmilli"
+	FormatUnixNano    = "unixnano"
+
+	// formats of time.Duration
+	FormatSeconds      = "seconds"
+	FormatMilliseconds = "ms"
+	FormatNanoseconds  = "ns"
+	FormatString       = "string"
+)
+
+var (
+	// {id:%08d}
+	VerbRe = regexp.MustCompile(`^%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]$`)
+
+	// printf verbs of basic kinds; %v fits all
+	basicVerbs = map[types.BasicInfo]string{
+		types.IsBoolean: "t",
+		types.IsInteger: "bcdoOqxXU",
+		types.IsFloat:   "beEfFgGxX",
+		types.IsComplex: "beEfFgGxX",
+		types.IsString:  "sqxX",
+	}
+)
+
+type (
+	// value of a formatted id, assigned to a local before the request
+	FormatMeta struct {
+		id      string
+		value   *Statement
+		marshal bool   // value is an encoding.TextMarshaler
+		escape  string // url.PathEscape or url.QueryEscape in uri; empty if unescaped
+	}
+)
+
+// placeholder of {id:format} in pattern and the arg filling it;
+// values in the uri, but plain ints, are escaped, so that a '/', '?' or '#' of them cannot change it
+func (meta *MethodMeta) formatId(id, format, escape string) (placeholder, arg string, err error) {
+	paramMeta, exist := meta.totalIds[id]
+	if !exist {
+		err = IdNotExistError(id)
+	}
+
+	if err == nil {
+		verb := VerbRe.MatchString(format)
+		switch {
+		case verb && !fitsVerb(paramMeta.goType, format[len(format)-1]):
+			err = PatternVerbMismatchError(id, format, paramMeta.goType.String())
+		case verb && escape == ZeroStr:
+			placeholder, arg = format, paramMeta.key
+		case format == ZeroStr && paramMeta.typ == TypeString && escape == ZeroStr:
+			placeholder, arg = StringPlaceholder, paramMeta.key
+		case format == ZeroStr && paramMeta.typ == TypeInt:
+			placeholder, arg = IntPlaceholder, paramMeta.key
+		default:
+			placeholder = StringPlaceholder
+			arg, err = meta.addFormat(id, format, escape, paramMeta)
+		}
+	}
+	return
+}
+
+// an id formatted and escaped the same way by several patterns is computed once
+func (meta *MethodMeta) addFormat(id, format, escape string, paramMeta *ParamMeta) (local string, err error) {
+	key := id + ":" + format
+	if escape != ZeroStr {
+		key += " " + escape
+	}
+	if local = meta.formatIds[key]; local == ZeroStr {
+		formatMeta := &FormatMeta{id: fmt.Sprintf("%s%d", IdFormat, len(meta.formats)), escape: escape}
+		err = formatMeta.resolve(id, format, paramMeta)
+		if err == nil {
+			Log.Debugf("Format {%s} -> %s", key, formatMeta.id)
+			local = formatMeta.id
+			meta.formats = append(meta.formats, formatMeta)
+			meta.formatIds[key] = local
+		}
+	}
+	return
+}
+
+func (meta *FormatMeta) resolve(id, format string, paramMeta *ParamMeta) (err error) {
+	value, ok := Id(paramMeta.key), true
+	switch {
+	case VerbRe.MatchString(format):
+		meta.value = Qual(FormatPkg, "Sprintf").Call(Lit(format), value)
+	case format == ZeroStr && paramMeta.typ == TypeString:
+		meta.value = value
+	case paramMeta.goType.String() == GetType(TypeTime).String():
+		meta.value, meta.marshal, ok = formatTime(value, format)
+	case paramMeta.goType.String() == GetType(TypeDuration).String():
+		meta.value, ok = formatDuration(value, format)
+	default:
+		switch {
+		case format != ZeroStr:
+			ok = false
+		case isTextMarshaler(paramMeta.goType):
+			meta.value, meta.marshal = value, true
+		case isStringer(paramMeta.goType):
+			meta.value = value.Dot("String").Call()
+		case isBasic(paramMeta.goType):
+			meta.value = Qual(FormatPkg, "Sprint").Call(value)
+		default:
+			err = PatternIdTypeUnsupportedError(id)
+		}
+	}
+	if !ok {
+		err = PatternFormatUnsupportedError(id, format)
+	}
+	return
+}
+
+// time.Time is marshaled as RFC 3339 with nanoseconds by default; a layout containing digits is used as is
+func formatTime(value *Statement, format string) (code *Statement, marshal bool, ok bool) {
+	ok = true
+	switch format {
+	case ZeroStr:
+		code, marshal = value, true
+	case FormatRFC3339:
+		code = value.Dot("Format").Call(Qual(TimePkg, "RFC3339"))
+	case FormatRFC3339Nano:
+		code = value.Dot("Format").Call(Qual(TimePkg, "RFC3339Nano"))
+	case FormatHttpTime:
+		code = value.Dot("UTC").Call().Dot("Format").Call(Qual(HttpPkg, "TimeFormat"))
+	case FormatDate:
+		code = value.Dot("Format").Call(Lit("2006-01-02"))
+	case FormatUnix:
+		code = Qual(StrconvPkg, "FormatInt").Call(value.Dot("Unix").Call(), Lit(10))
+	case FormatUnixMilli:
+		code = Qual(StrconvPkg, "FormatInt").Call(value.Dot("UnixNano").Call().Op("/").Int64().Call(Qual(TimePkg, "Millisecond")), Lit(10))
+	case FormatUnixNano:
+		code = Qual(StrconvPkg, "FormatInt").Call(value.Dot("UnixNano").Call(), Lit(10))
+	default:
+		code, ok = value.Dot("Format").Call(Lit(format)), strings.ContainsAny(format, "0123456789")
+	}
+	return
+}
+
+// time.Duration is formatted by String() by default
+func formatDuration(value *Statement, format string) (code *Statement, ok bool) {
+	ok = true
+	switch format {
+	case ZeroStr, FormatString:
+		code = value.Dot("String").Call()
+	case FormatSeconds:
+		code = Qual(StrconvPkg, "FormatFloat").Call(value.Dot("Seconds").Call(), LitRune('f'), Lit(-1), Lit(64))
+	case FormatMilliseconds:
+		code = Qual(StrconvPkg, "FormatInt").Call(value.Dot("Milliseconds").Call(), Lit(10))
+	case FormatNanoseconds:
+		code = Qual(StrconvPkg, "FormatInt").Call(value.Dot("Nanoseconds").Call(), Lit(10))
+	default:
+		ok = false
+	}
+	return
+}
+
+// genFormat0 := since.Format(time.RFC3339)
+func (method *Method) genFormats(group *Group) {
+	for _, format := range method.formats {
+		if format.marshal {
+			group.Var().Id(format.id).String()
+			group.If(
+				List(Id(format.id), Id(IdError)).Op("=").Qual(ClientPkg, "MarshalText").Call(format.value),
+				Id(IdError).Op("!=").Nil(),
+			).Block(Return())
+			if format.escape != ZeroStr {
+				group.Id(format.id).Op("=").Qual(NetURL, format.escape).Call(Id(format.id))
+			}
+		} else if format.escape != ZeroStr {
+			group.Id(format.id).Op(":=").Qual(NetURL, format.escape).Call(format.value)
+		} else {
+			group.Id(format.id).Op(":=").Add(format.value)
+		}
+	}
+}
+
+// if genText, genTextErr := client.MarshalText(value); genTextErr == nil { add(key, genText) } else { ... }
+func marshalText(key Code, value *Statement, add FormAdder) Code {
+	return If(
+		List(Id(IdText), Id(IdTextErr)).Op(":=").Qual(ClientPkg, "MarshalText").Call(value),
+		Id(IdTextErr).Op("==").Nil(),
+	).Block(
+		add(key, Id(IdText)),
+	).Else().Block(
+		Id(IdError).Op("=").Id(IdTextErr),
+		Return(),
+	)
+}
+
+// the verb prints typ as fmt does, not as %!d(...)
+func fitsVerb(typ types.Type, verb byte) bool {
+	if verb == 'v' {
+		return true
+	}
+	if basic, ok := typ.Underlying().(*types.Basic); ok {
+		for info, verbs := range basicVerbs {
+			if basic.Info()&info != 0 && strings.IndexByte(verbs, verb) >= 0 {
+				return true
+			}
+		}
+		return false
+	}
+	// fmt.Stringers and []byte are printed as strings
+	return (isStringer(typ) || types.Identical(typ.Underlying(), GetType(TypeBytes))) && strings.IndexByte(basicVerbs[types.IsString], verb) >= 0
+}
+
+func isTextMarshaler(typ types.Type) bool {
+	textMarshaler := GetType(TypeTextMarshaler).Underlying().(*types.Interface)
+	return types.Implements(typ, textMarshaler)
+}
+
+func isBasic(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) != 0
+}
+
+// /items/{since:rfc3339} -> /items/{since}
+func stripFormats(pattern string) string {
+	return IdRe.ReplaceAllStringFunc(pattern, func(id string) string {
+		return "{" + getIdFromPattern(id) + "}"
+	})
+}
@@ -0,0 +1,95 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"github.com/stretchr/testify/assert"
+	"go/types"
+	"testing"
+)
+
+func TestFormatPattern(t *testing.T) {
+	meta := &MethodMeta{
+		idList:    make(IdList),
+		formatIds: make(map[string]string),
+		totalIds: map[string]*ParamMeta{
+			"id":     {key: "id", typ: TypeInt, goType: types.Typ[types.Int]},
+			"since":  {key: "since", typ: Other, goType: GetType(TypeTime)},
+			"window": {key: "window", typ: Other, goType: GetType(TypeDuration)},
+			"ok":     {key: "ok", typ: Other, goType: types.Typ[types.Bool]},
+			"ptr":    {key: "ptr", typ: Other, goType: types.NewPointer(types.Typ[types.Int])},
+		},
+	}
+
+	pattern, err := meta.genUriMeta("/items/{id:%08d}/{since:date}?since={since:rfc3339}&at={since:unix}&w={window:seconds}&ok={ok}&s={since:rfc3339}")
+	assert.Nil(t, err)
+	assert.Equal(t, "/items/%s/%s?since=%s&at=%s&w=%s&ok=%s&s=%s", pattern.pattern)
+	assert.Equal(t, []string{"genFormat0", "genFormat1", "genFormat2", "genFormat3", "genFormat4", "genFormat5", "genFormat2"}, pattern.ids)
+
+	pattern, err = meta.genPatternMeta("X-Since", "{since:rfc3339}")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"genFormat6"}, pattern.ids)
+
+	group := BlockFunc((&Method{MethodMeta: meta}).genFormats)
+	assert.Equal(t, `{
+	genFormat0 := url.PathEscape(fmt.Sprintf("%08d", id))
+	genFormat1 := url.PathEscape(since.Format("2006-01-02"))
+	genFormat2 := url.QueryEscape(since.Format(time.RFC3339))
+	genFormat3 := url.QueryEscape(strconv.FormatInt(since.Unix(), 10))
+	genFormat4 := url.QueryEscape(strconv.FormatFloat(window.Seconds(), 'f', -1, 64))
+	genFormat5 := url.QueryEscape(fmt.Sprint(ok))
+	genFormat6 := since.Format(time.RFC3339)
+}`, group.GoString())
+
+	_, err = meta.genPatternMeta("uri", "/items/{since:weekday}")
+	assert.EqualError(t, err, PatternFormatUnsupportedError("since", "weekday").Error())
+
+	_, err = meta.genPatternMeta("uri", "/items/{id:rfc3339}")
+	assert.EqualError(t, err, PatternFormatUnsupportedError("id", "rfc3339").Error())
+
+	_, err = meta.genPatternMeta("uri", "/items/{since:%d}")
+	assert.EqualError(t, err, PatternVerbMismatchError("since", "%d", "time.Time").Error())
+
+	_, err = meta.genPatternMeta("uri", "/items/{ok:%x}")
+	assert.EqualError(t, err, PatternVerbMismatchError("ok", "%x", "bool").Error())
+
+	_, err = meta.genPatternMeta("uri", "/items/{since:%s}/{ok:%t}/{window:%05d}")
+	assert.Nil(t, err)
+
+	_, err = meta.genPatternMeta("uri", "/items/{ptr}")
+	assert.EqualError(t, err, PatternIdTypeUnsupportedError("ptr").Error())
+
+	assert.Equal(t, "/items/{since}/{id}", stripFormats("/items/{since:2006-01-02}/{id:%d}"))
+
+	assert.Nil(t, meta.TrySetMethod("GET", "/items/{id:%x}"))
+	assert.Equal(t, "/items/%s", meta.uri.pattern)
+}
+
+func TestFormatPattern_Escape(t *testing.T) {
+	meta := &MethodMeta{
+		idList:    make(IdList),
+		formatIds: make(map[string]string),
+		totalIds: map[string]*ParamMeta{
+			"id":   {key: "id", typ: TypeInt, goType: types.Typ[types.Int]},
+			"name": {key: "name", typ: TypeString, goType: types.Typ[types.String]},
+		},
+	}
+
+	// a name of "a/b?c#d" stays in one path segment
+	pattern, err := meta.genUriMeta("/items/{id}/{name}/{name:%s}?q={name:%q}")
+	assert.Nil(t, err)
+	assert.Equal(t, "/items/%d/%s/%s?q=%s", pattern.pattern)
+	assert.Equal(t, []string{"id", "genFormat0", "genFormat1", "genFormat2"}, pattern.ids)
+
+	// headers are not escaped
+	pattern, err = meta.genPatternMeta("X-Name", "{name:%s}/{name}")
+	assert.Nil(t, err)
+	assert.Equal(t, "%s/%s", pattern.pattern)
+	assert.Equal(t, []string{"name", "name"}, pattern.ids)
+
+	group := BlockFunc((&Method{MethodMeta: meta}).genFormats)
+	assert.Equal(t, `{
+	genFormat0 := url.PathEscape(name)
+	genFormat1 := url.PathEscape(fmt.Sprintf("%s", name))
+	genFormat2 := url.QueryEscape(fmt.Sprintf("%q", name))
+}`, group.GoString())
+}
@@ -1,9 +1,10 @@
 package impl
 
 import (
-	"fmt"
+	"bytes"
 	. "github.com/dave/jennifer/jen"
 	. "github.com/rady-io/http-service/log"
+	"go/types"
 	"strings"
 )
 
@@ -11,6 +12,7 @@ const (
 	FieldBaseUrl = "baseUrl"
 	FieldHeader  = "header"
 	FieldCookies = "cookies"
+	FieldConfig  = "config"
 )
 
 const (
@@ -25,9 +27,20 @@ const (
 	MultipartPkg = "mime/multipart"
 	Textproto    = "net/textproto"
 	OS           = "os"
+	MIMEPkg      = "mime"
+	PathPkg      = "path"
+	FilepathPkg  = "path/filepath"
+	FSPkg        = "io/fs"
 	StringsPkg   = "strings"
+	ReflectPkg   = "reflect"
 	FormatPkg    = "fmt"
+	TimePkg      = "time"
+	ContextPkg   = "context"
+	StrconvPkg   = "strconv"
 	UnHTMLPkg    = "github.com/Hexilee/unhtml"
+	StreamPkg    = "github.com/rady-io/http-service/stream"
+	CompressPkg  = "github.com/rady-io/http-service/compression"
+	ClientPkg    = "github.com/rady-io/http-service/client"
 )
 
 const (
@@ -37,21 +50,47 @@ const (
 	ErrorToken  = "error"
 )
 
+// Impl generates code of service in its own package
 func Impl(service *Service, pkg string) (code string, err error) {
+	return ImplTo(service, pkg, pkg, ZeroStr)
+}
+
+// ImplTo generates code of service in package outPkg named outName, which imports pkg if they differ;
+// an empty outName is guessed from outPkg
+func ImplTo(service *Service, pkg, outPkg, outName string) (code string, err error) {
 	Log.Infof("Implement Service: %s", service.name)
-	service.newFunc = "New" + service.name
-	service.implName = strings.ToLower(service.name) + "Impl"
-	service.self = strings.ToLower(service.name)
-	service.pkg = pkg
-	file := NewFilePath(pkg)
+	service.prepare(pkg, outPkg)
+	file := NewFilePath(outPkg)
+	if outName != ZeroStr {
+		file = NewFilePathName(outPkg, outName)
+	}
 	err = service.resolveMetadata()
 	if err == nil {
 		err = service.resolveCode(file)
-		code = fmt.Sprintf("%#v", file)
+	}
+	if err == nil {
+		code, err = render(file)
+	}
+	return
+}
+
+// render reports invalid generated code, e.g. a bad package name, instead of writing it
+func render(file *File) (code string, err error) {
+	buf := new(bytes.Buffer)
+	if err = file.Render(buf); err == nil {
+		code = buf.String()
 	}
 	return
 }
 
+func (service *Service) prepare(pkg, outPkg string) {
+	service.newFunc = "New" + service.name
+	service.implName = strings.ToLower(service.name) + "Impl"
+	service.self = strings.ToLower(service.name)
+	service.pkg = pkg
+	service.implPkg = outPkg
+}
+
 // *net/http.Response -> Op("*").Qual("net/http", "Response")
 func getQual(typ string) *Statement {
 	if !strings.Contains(typ, ".") {
@@ -78,3 +117,37 @@ func getQual(typ string) *Statement {
 	}
 	return statement
 }
+
+// <-chan *net/http.Response -> Op("<-").Chan().Op("*").Qual("net/http", "Response")
+func getTypeQual(typ types.Type) *Statement {
+	var statement *Statement
+	switch typ := typ.(type) {
+	case *types.Pointer:
+		statement = Op("*").Add(getTypeQual(typ.Elem()))
+	case *types.Slice:
+		statement = Index().Add(getTypeQual(typ.Elem()))
+	case *types.Array:
+		statement = Index(Lit(int(typ.Len()))).Add(getTypeQual(typ.Elem()))
+	case *types.Map:
+		statement = Map(getTypeQual(typ.Key())).Add(getTypeQual(typ.Elem()))
+	case *types.Chan:
+		switch typ.Dir() {
+		case types.RecvOnly:
+			statement = Op("<-").Chan()
+		case types.SendOnly:
+			statement = Chan().Op("<-")
+		default:
+			statement = Chan()
+		}
+		statement = statement.Add(getTypeQual(typ.Elem()))
+	case *types.Named:
+		if typ.Obj().Pkg() == nil {
+			statement = Id(typ.Obj().Name())
+		} else {
+			statement = Qual(typ.Obj().Pkg().Path(), typ.Obj().Name())
+		}
+	default:
+		statement = getQual(typ.String())
+	}
+	return statement
+}
@@ -0,0 +1,106 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	. "github.com/rady-io/http-service/log"
+	"net/http"
+)
+
+const (
+	// values of @LeftParams
+	LeftQuery = "query" // form-encoded into the query
+	LeftBody  = "body"  // fields of the body, as other methods
+	LeftError = "error"
+)
+
+func (meta *ServiceMeta) trySetLeftMode(value string) (err error) {
+	meta.leftMode, err = parseLeftMode(meta.leftMode, value)
+	return
+}
+
+func (meta *MethodMeta) TrySetLeftMode(value string) (err error) {
+	meta.leftMode, err = parseLeftMode(meta.leftMode, value)
+	return
+}
+
+func parseLeftMode(current, value string) (mode string, err error) {
+	mode = current
+	if current != ZeroStr {
+		err = DuplicatedAnnotationError(LeftParamsAnn)
+	} else if value != LeftQuery && value != LeftBody && value != LeftError {
+		err = UnsupportedAnnotationValueError(LeftParamsAnn, value)
+	}
+	if err == nil {
+		Log.Debugf("Set Left Params: %s", value)
+		mode = value
+	}
+	return
+}
+
+// GET, HEAD and DELETE requests are sent without body by many clients and rejected by many servers
+func (meta *MethodMeta) bodiless() bool {
+	return meta.httpMethod == ZeroStr || meta.httpMethod == http.MethodGet ||
+		meta.httpMethod == http.MethodHead || meta.httpMethod == http.MethodDelete
+}
+
+func (meta *MethodMeta) leftWithoutBody() bool {
+	return meta.bodiless() && meta.leftMode != LeftBody
+}
+
+// left ids of a method without body go to the query by default
+func (method *Method) resolveLeftParams() (err error) {
+	left := method.sortedLeftIds()
+	if len(left) == 0 || !method.leftWithoutBody() {
+		method.resolveLeftIds()
+		return
+	}
+	if method.leftMode == LeftError {
+		return LeftParamsWithoutBodyError(left)
+	}
+	for _, id := range left {
+		if fields, isStruct := method.structVars[id]; isStruct {
+			method.queryVars = append(method.queryVars, structBodyVars(fields, FormTag)...)
+		} else {
+			paramMeta := method.totalIds[id]
+			Log.Debugf("Set Query(%s) <- %s", id, paramMeta.key)
+			method.queryVars = append(method.queryVars, &BodyMeta{
+				PatternMeta: &PatternMeta{key: id, ids: []string{paramMeta.key}},
+				typ:         paramMeta.typ,
+				goType:      paramMeta.goType,
+			})
+		}
+	}
+	for _, queryVar := range method.queryVars {
+		if _, err = encodeFormValue(Lit(queryVar.key), Id(queryVar.ids[0]), queryVar.goType, nopAdder); err != nil {
+			break
+		}
+	}
+	return
+}
+
+// left ids in the order of params
+func (method *Method) sortedLeftIds() []string {
+	left := make([]string, 0, len(method.idList))
+	params := method.signature.Params()
+	for i := 0; i < params.Len(); i++ {
+		if _, ok := method.idList[params.At(i).Name()]; ok {
+			left = append(left, params.At(i).Name())
+		}
+	}
+	return left
+}
+
+func (method *Method) addQueryVars(group *Group, add FormAdder) {
+	for _, queryVar := range method.queryVars {
+		statements, _ := encodeFormValue(Lit(queryVar.key), Id(queryVar.ids[0]), queryVar.goType, add)
+		if queryVar.condition != nil {
+			group.If(queryVar.condition).Block(statements...)
+		} else {
+			addStatements(group, statements)
+		}
+	}
+}
+
+func nopAdder(key Code, value Code) *Statement {
+	return Null()
+}
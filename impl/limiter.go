@@ -0,0 +1,152 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// options of @RateLimit. etc. @RateLimit 10/s burst=20 fail adaptive
+	BurstOption    = "burst"
+	FailOption     = "fail"     // fail fast instead of waiting for a token
+	AdaptiveOption = "adaptive" // pause as told by Retry-After and X-RateLimit-* headers
+
+	FieldLimiters = "limiters"
+)
+
+type (
+	RateLimitMeta struct {
+		rate     float64 // per second
+		burst    int
+		failFast bool
+		adaptive bool
+	}
+)
+
+// 10/s | 100/m | 1/500ms, with options; burst defaults to the ceil of rate
+func parseRateLimit(value string) (limit *RateLimitMeta, err error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, UnsupportedAnnotationValueError(RateLimitAnn, value)
+	}
+	limit = new(RateLimitMeta)
+	limit.rate, err = parseRate(fields[0])
+	if err == nil {
+		limit.burst = int(limit.rate)
+		if float64(limit.burst) < limit.rate || limit.burst == 0 {
+			limit.burst++
+		}
+	}
+	for _, option := range fields[1:] {
+		if err != nil {
+			break
+		}
+		switch {
+		case option == FailOption:
+			limit.failFast = true
+		case option == AdaptiveOption:
+			limit.adaptive = true
+		case strings.HasPrefix(option, BurstOption+"="):
+			limit.burst, err = strconv.Atoi(strings.TrimPrefix(option, BurstOption+"="))
+			if err == nil && limit.burst <= 0 {
+				err = UnsupportedAnnotationValueError(RateLimitAnn, option)
+			}
+		default:
+			err = UnsupportedAnnotationValueError(RateLimitAnn, option)
+		}
+	}
+	if err != nil {
+		limit, err = nil, UnsupportedAnnotationValueError(RateLimitAnn, value)
+	}
+	return
+}
+
+func parseRate(rate string) (perSecond float64, err error) {
+	slash := strings.Index(rate, "/")
+	if slash == -1 {
+		return 0, UnsupportedAnnotationValueError(RateLimitAnn, rate)
+	}
+	var count float64
+	var per time.Duration
+	count, err = strconv.ParseFloat(rate[:slash], 64)
+	if err == nil {
+		unit := rate[slash+1:]
+		if unit != ZeroStr && (unit[0] < '0' || unit[0] > '9') {
+			unit = "1" + unit
+		}
+		per, err = time.ParseDuration(unit)
+	}
+	if err == nil && (count <= 0 || per <= 0) {
+		err = UnsupportedAnnotationValueError(RateLimitAnn, rate)
+	}
+	if err == nil {
+		perSecond = count / per.Seconds()
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetRateLimit(value string) (err error) {
+	if meta.rateLimit != nil {
+		err = DuplicatedAnnotationError(RateLimitAnn)
+	}
+	if err == nil {
+		meta.rateLimit, err = parseRateLimit(value)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetRateLimit(value string) (err error) {
+	if meta.rateLimit != nil {
+		err = DuplicatedAnnotationError(RateLimitAnn)
+	}
+	if err == nil {
+		meta.rateLimit, err = parseRateLimit(value)
+	}
+	return
+}
+
+// the service limiter is keyed by the service name, and a method limiter by the method name
+func (srv *Service) genLimiters(group *Group) {
+	newLimiter := func(name string, limit *RateLimitMeta) {
+		limiter := Qual(ClientPkg, "NewLimiter").Call(Lit(name), Lit(limit.rate), Lit(limit.burst))
+		if limit.failFast {
+			limiter = limiter.Dot("FailFast").Call()
+		}
+		if limit.adaptive {
+			limiter = limiter.Dot("Adaptive").Call()
+		}
+		group.Id(srv.self).Dot(FieldLimiters).Index(Lit(name)).Op("=").Add(limiter)
+	}
+	if srv.rateLimit != nil {
+		newLimiter(srv.name, srv.rateLimit)
+	}
+	for _, method := range srv.sortedMethods() {
+		if method.rateLimit != nil {
+			newLimiter(method.Name(), method.rateLimit)
+		}
+	}
+}
+
+// wait for a token before making the request, respecting the context of request
+func (method *Method) waitLimiter(group *Group) {
+	if method.limiterKey != ZeroStr {
+		group.Id(IdError).Op("=").Id(method.service.self).Dot(FieldLimiters).Index(Lit(method.limiterKey)).
+			Dot("Wait").Call(Id(IdRequest).Dot("Context").Call())
+		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+	}
+}
+
+func (method *Method) observeLimiter(group *Group) {
+	if method.limiterKey != ZeroStr && method.rateLimitAdaptive() {
+		group.Id(method.service.self).Dot(FieldLimiters).Index(Lit(method.limiterKey)).Dot("Observe").Call(Id(IdResponse))
+	}
+}
+
+func (method *Method) rateLimitAdaptive() bool {
+	if method.rateLimit != nil {
+		return method.rateLimit.adaptive
+	}
+	return method.service.rateLimit.adaptive
+}
@@ -0,0 +1,222 @@
+package impl
+
+import (
+	"fmt"
+	. "github.com/rady-io/http-service/log"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// the same as the annotation processor
+	AnnRegexp = `@[a-zA-Z_][0-9a-zA-Z_]*`
+)
+
+var (
+	AnnRe = regexp.MustCompile(AnnRegexp)
+
+	serviceAnns = annSet(HttpServiceAnn, BaseAnn, HeaderAnn, CookieAnn, CompressAnn, BodyAnn, ResultAnn, AcceptAnn,
+		CircuitBreakerAnn, RateLimitAnn, CacheAnn, SingleflightAnn, TimeoutAnn, RetryAnn, LeftParamsAnn)
+	methodAnns = annSet(GetAnn, HeadAnn, PostAnn, PutAnn, PatchAnn, DeleteAnn, ConnectAnn, OptionsAnn, TraceAnn,
+		BodyAnn, SingleBodyAnn, ResultAnn, ParamAnn, HeaderAnn, CookieAnn, HeadersAnn, CookiesAnn, FileAnn,
+		CompressAnn, AcceptAnn, CircuitBreakerAnn, RateLimitAnn, CacheAnn, SingleflightAnn, TimeoutAnn, RetryAnn, LeftParamsAnn)
+	httpMethodAnns = annSet(GetAnn, HeadAnn, PostAnn, PutAnn, PatchAnn, DeleteAnn, ConnectAnn, OptionsAnn, TraceAnn)
+)
+
+type (
+	Diagnostic struct {
+		Pos     token.Pos
+		Message string
+		Fixes   []*Fix
+	}
+
+	// Fix replaces text in [Pos, End) by NewText; inserts if End is Pos
+	Fix struct {
+		Message  string
+		Pos, End token.Pos
+		NewText  string
+	}
+
+	// first position of each annotation in comments
+	AnnPositions map[string]*AnnPos
+
+	AnnPos struct {
+		pos    token.Pos
+		prefix string // text before the annotation in its line. etc. "\t\t"
+	}
+)
+
+func annSet(anns ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, ann := range anns {
+		set[ann] = true
+	}
+	return set
+}
+
+// Lint resolves every method of service and reports errors and suspicious annotations in the order of position:
+// unknown annotations, unused params sent in an implicit body, bodies of GET or HEAD and duplicated routes
+func Lint(service *Service, pkg string) (diagnostics []*Diagnostic) {
+	Log.Infof("Lint Service: %s", service.name)
+	service.prepare(pkg, pkg)
+	diagnostics = make([]*Diagnostic, 0)
+	report := func(pos token.Pos, fixes []*Fix, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, &Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...), Fixes: fixes})
+	}
+	defer func() {
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			return diagnostics[i].Pos < diagnostics[j].Pos
+		})
+	}()
+
+	if err := service.resolveMetadata(); err != nil {
+		report(service.pos, nil, "%s", err)
+		return
+	}
+	serviceAnnPos := scanAnnPositions(service.docs, serviceAnns, "service", report)
+	routes := make(map[string]*Method)
+	for _, method := range service.sortedMethods() {
+		annPos := scanAnnPositions(method.docs, methodAnns, "method", report)
+		if err := method.resolveMetadata(); err != nil {
+			report(method.Pos(), nil, "%s", err)
+			continue
+		}
+		httpMethodPos := method.Pos()
+		var httpMethodAnn *AnnPos
+		for ann := range httpMethodAnns {
+			if position, ok := annPos[ann]; ok {
+				httpMethodPos, httpMethodAnn = position.pos, position
+			}
+		}
+
+		if !hasAnn(annPos, BodyAnn) && !hasAnn(annPos, SingleBodyAnn) && !hasAnn(serviceAnnPos, BodyAnn) && !method.leftWithoutBody() {
+			var fixes []*Fix
+			if httpMethodAnn != nil {
+				// a line above the http method
+				fixes = []*Fix{{
+					Message: "Add " + BodyAnn + " " + JSON,
+					Pos:     httpMethodAnn.pos,
+					End:     httpMethodAnn.pos,
+					NewText: BodyAnn + " " + JSON + LF + httpMethodAnn.prefix,
+				}}
+			}
+			for _, param := range method.leftParams() {
+				report(param.Pos(), fixes, "param %s is not used by any annotation and is sent in the %s body of %s", param.Name(), method.requestType, method.Name())
+			}
+		}
+		if httpMethod := method.getHttpMethod(); (httpMethod == http.MethodGet || httpMethod == http.MethodHead) && len(method.bodyVars) != 0 {
+			keys := make([]string, 0, len(method.bodyVars))
+			for _, bodyVar := range method.bodyVars {
+				keys = append(keys, bodyVar.key)
+			}
+			sort.Strings(keys)
+			report(httpMethodPos, nil, "%s request of %s has a body: %s", httpMethod, method.Name(), strings.Join(keys, ", "))
+		}
+		route := method.getHttpMethod() + " " + method.route
+		if first, ok := routes[route]; ok {
+			report(httpMethodPos, nil, "route %s of %s is also used by %s", route, method.Name(), first.Name())
+		} else {
+			routes[route] = method
+		}
+	}
+	return
+}
+
+func hasAnn(positions AnnPositions, ann string) bool {
+	_, ok := positions[ann]
+	return ok
+}
+
+// annotations in raw comment lines, matched as the annotation processor does; unknown ones are reported
+func scanAnnPositions(docs []*ast.CommentGroup, known map[string]bool, owner string,
+	report func(pos token.Pos, fixes []*Fix, format string, args ...interface{})) AnnPositions {
+	positions := make(AnnPositions)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, comment := range doc.List {
+			offset := 0
+			for _, line := range strings.Split(comment.Text, LF) {
+				if loc := AnnRe.FindStringIndex(line); loc != nil {
+					ann, pos := line[loc[0]:loc[1]], comment.Slash+token.Pos(offset+loc[0])
+					if !known[ann] && (serviceAnns[ann] || methodAnns[ann]) {
+						report(pos, nil, "annotation %s is ignored on a %s", ann, owner)
+					} else if !known[ann] {
+						var fixes []*Fix
+						if similar := similarAnn(ann, known); similar != ZeroStr {
+							fixes = []*Fix{{Message: "Replace with " + similar, Pos: pos, End: pos + token.Pos(len(ann)), NewText: similar}}
+						}
+						report(pos, fixes, "unknown annotation %s", ann)
+					} else if _, ok := positions[ann]; !ok {
+						positions[ann] = &AnnPos{pos: pos, prefix: line[:loc[0]]}
+					}
+				}
+				offset += len(line) + len(LF)
+			}
+		}
+	}
+	return positions
+}
+
+// the known annotation closest to ann within 2 edits, ignoring case; empty if none
+func similarAnn(ann string, known map[string]bool) (similar string) {
+	best := 3
+	for candidate := range known {
+		if distance := editDistance(strings.ToLower(ann), strings.ToLower(candidate)); distance < best ||
+			distance == best && candidate < similar {
+			similar, best = candidate, distance
+		}
+	}
+	return
+}
+
+// levenshtein distance of bytes
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		previous := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			current := row[j]
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min3(row[j]+1, row[j-1]+1, previous+cost)
+			previous = current
+		}
+	}
+	return row[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// params left for the body, except expanded struct params
+func (method *Method) leftParams() []*types.Var {
+	left := make([]*types.Var, 0)
+	params := method.signature.Params()
+	for i := 0; i < params.Len(); i++ {
+		_, isStruct := method.structVars[params.At(i).Name()]
+		if _, ok := method.idList[params.At(i).Name()]; ok && !isStruct {
+			left = append(left, params.At(i))
+		}
+	}
+	return left
+}
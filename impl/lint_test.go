@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+const lintSource = `package lint
+
+import "net/http"
+
+// @HttpService
+type (
+	/*
+	@Base http://example.com
+	*/
+	Service interface {
+		/*
+		@Gett /items
+		@Get /items/{id}
+		@LeftParams body
+		*/
+		List(id int, page int) (*http.Response, error)
+
+		/*
+		@Get /items/{id}
+		@Body json
+		*/
+		Get(id int) (*http.Response, error)
+
+		/*
+		@Delete /items/{id}
+		@LeftParams error
+		*/
+		Delete(id int, force bool) (*http.Response, error)
+
+		/*
+		@Get /events
+		@Result ndjson
+		*/
+		Events() (<-chan int, <-chan error, error)
+
+		/*
+		@Get /raw
+		@Timeout 1s
+		*/
+		Raw() (*http.Response, error)
+	}
+)
+`
+
+// diagnostics of Service in filename, or in src if not nil
+func lintMessages(t *testing.T, filename string, src interface{}) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	assert.Nil(t, err)
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	_, err = (&types.Config{Importer: importer.Default()}).Check(file.Name.Name, fset, []*ast.File{file}, info)
+	assert.Nil(t, err)
+
+	service := NewService("Service", info).InitComments(ast.NewCommentMap(fset, file, file.Comments))
+	messages := make([]string, 0)
+	for _, diagnostic := range Lint(service, file.Name.Name) {
+		messages = append(messages, fset.Position(diagnostic.Pos).String()+": "+diagnostic.Message)
+	}
+	return messages
+}
+
+func TestLint(t *testing.T) {
+	assert.Equal(t, []string{
+		"lint.go:12:3: unknown annotation @Gett",
+		"lint.go:13:3: GET request of List has a body: page",
+		"lint.go:16:16: param page is not used by any annotation and is sent in the json body of List",
+		"lint.go:19:3: route GET /items/{id} of Get is also used by List",
+		"lint.go:28:3: params are not used by any annotation of a method without body: force",
+		"lint.go:34:3: method with a stream result must have a context.Context param",
+		"lint.go:40:3: annotation conflict: @Timeout <!> func() (*net/http.Response, error)",
+	}, lintMessages(t, "lint.go", lintSource))
+}
+
+// the service of the generator fixture is clean
+func TestLint_Fixture(t *testing.T) {
+	assert.Empty(t, lintMessages(t, "../test/service.go", nil))
+}
@@ -4,18 +4,21 @@ import (
 	"errors"
 	. "github.com/dave/jennifer/jen"
 	"github.com/rady-io/annotation-processor"
+	"github.com/rady-io/http-service/compression"
 	"github.com/rady-io/http-service/headers"
 	. "github.com/rady-io/http-service/log"
+	"go/ast"
 	"go/types"
 	"log"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const (
-	IdRegexp          = `\{[a-zA-Z_][0-9a-zA-Z_]*\}`
+	IdRegexp          = `\{[a-zA-Z_][0-9a-zA-Z_]*(:[^{}]+)?\}` // {id} or {id:format}
 	StringPlaceholder = "%s"
 	IntPlaceholder    = "%d"
 )
@@ -43,6 +46,22 @@ const (
 	IdHeaderSlice = "genHeaderSlice"
 	IdHeaderValue = "genHeaderValue"
 	IdHeader      = "genHeader"
+	IdStream      = "genStream"
+	IdStreamErr   = "genStreamErr"
+	IdErrChan     = "genErrChan"
+	IdDecoder     = "genDecoder"
+	IdItem        = "genItem"
+	IdQuery       = "genQuery"
+	IdValue       = "genValue"
+	IdFormKey     = "genKey"
+	IdPartHeader  = "genPartHeader"
+	IdCookieName  = "genCookieName"
+	IdCookieValue = "genCookieValue"
+	IdMediaType   = "genMediaType"
+	IdOptions     = "genOptions"
+	IdFormat      = "genFormat"
+	IdText        = "genText"
+	IdTextErr     = "genTextErr"
 )
 
 var (
@@ -53,28 +72,51 @@ type (
 	Method struct {
 		*types.Func
 		commentText string
+		docs        []*ast.CommentGroup
 		service     *Service
 		signature   *types.Signature
 		*MethodMeta
 	}
 
 	MethodMeta struct {
-		idList      IdList // delete when a id is used; to get left ids
-		httpMethod  string
-		uri         *PatternMeta
-		headerVars  []*PatternMeta
-		cookieVars  []*PatternMeta
-		bodyVars    []*BodyMeta // left params as '@Param(id) {id}'
-		totalIds    map[string]*ParamMeta
-		responseIds []string
-		resultType  BodyType
-		requestType BodyType
-		singleBody  bool // json || xml
+		idList       IdList // delete when a id is used; to get left ids
+		contextId    string // param of type context.Context; never be a left id
+		httpMethod   string
+		uri          *PatternMeta
+		route        string // uri pattern without query, for tracing
+		headerVars   []*PatternMeta
+		cookieVars   []*CookieMeta
+		headerMaps   []*ParamMeta // @Headers {h}
+		cookieMaps   []*ParamMeta // @Cookies {c}
+		bodyVars     []*BodyMeta  // left params as '@Param(id) {id}'
+		totalIds     map[string]*ParamMeta
+		structVars   map[string][]*FieldMeta // struct params expanded by field tags
+		nilStructs   []*types.Var            // pointer struct params
+		responseIds  []string
+		resultType   BodyType
+		resultTypes  []BodyType // declared result types; the first is the default decoder
+		accept       string     // overrides Accept from result types
+		requestType  BodyType
+		singleBody   bool   // json || xml
+		compress     string // content encoding of body; inherit from service if empty
+		breaker      *BreakerMeta
+		breakerKey   string // key of the breaker used by method; empty if none
+		rateLimit    *RateLimitMeta
+		limiterKey   string // key of the limiter used by method; empty if none
+		cache        *CacheMeta
+		singleflight bool              // concurrent identical calls share one request
+		timeout      time.Duration     // of the call and decoding; none if zero
+		retry        *RetryMeta        // failed calls are retried on untried endpoints
+		leftMode     string            // @LeftParams; inherit from service if empty
+		queryVars    []*BodyMeta       // left params of a method without body
+		formats      []*FormatMeta     // formatted ids of patterns, computed before the request
+		formatIds    map[string]string // id:format -> local of the formatted value
 	}
 
 	ParamMeta struct {
-		key string
-		typ ParamType
+		key    string
+		typ    ParamType
+		goType types.Type
 	}
 
 	PatternMeta struct {
@@ -85,7 +127,10 @@ type (
 
 	BodyMeta struct {
 		*PatternMeta
-		typ ParamType
+		typ       ParamType
+		goType    types.Type // nil for annotated params
+		condition *Statement // nil if unconditional
+		file      *FileMeta  // only for TypeFile
 	}
 )
 
@@ -97,40 +142,59 @@ func NewMethod(srv *Service, rawMethod *types.Func) *Method {
 		MethodMeta: &MethodMeta{
 			idList:      make(IdList),
 			headerVars:  make([]*PatternMeta, 0),
-			cookieVars:  make([]*PatternMeta, 0),
+			cookieVars:  make([]*CookieMeta, 0),
+			headerMaps:  make([]*ParamMeta, 0),
+			cookieMaps:  make([]*ParamMeta, 0),
 			totalIds:    make(map[string]*ParamMeta),
+			structVars:  make(map[string][]*FieldMeta),
+			nilStructs:  make([]*types.Var, 0),
 			bodyVars:    make([]*BodyMeta, 0),
 			responseIds: make([]string, 0),
+			queryVars:   make([]*BodyMeta, 0),
+			formats:     make([]*FormatMeta, 0),
+			formatIds:   make(map[string]string),
 		},
 	}
 
 	params := method.signature.Params()
 	for i := 0; i < params.Len(); i++ {
 		param := params.At(i)
-		method.idList.addKey(param.Name())
-		method.totalIds[param.Name()] = NewParamMeta(param)
+		paramMeta := NewParamMeta(param)
+		method.totalIds[param.Name()] = paramMeta
+		if paramMeta.typ == Context {
+			method.contextId = param.Name()
+		} else {
+			method.idList.addKey(param.Name())
+		}
 	}
 
 	return method
 }
 
 func NewParamMeta(param *types.Var) (meta *ParamMeta) {
-	meta = &ParamMeta{key: param.Name()}
-	switch typ := param.Type().(type) {
+	meta = &ParamMeta{key: param.Name(), typ: getParamType(param.Type()), goType: param.Type()}
+	return
+}
+
+func getParamType(paramType types.Type) (typ ParamType) {
+	switch basic := paramType.(type) {
 	case *types.Basic:
-		switch typ.Kind() {
+		switch basic.Kind() {
 		case types.Int:
-			meta.typ = TypeInt
+			typ = TypeInt
 		case types.String:
-			meta.typ = TypeString
+			typ = TypeString
 		default:
-			meta.typ = Other
+			typ = Other
 		}
 	default:
-		meta.typ = Other
+		typ = Other
+	}
+	if GetType(TypeIOReader).String() == paramType.String() {
+		typ = IOReader
 	}
-	if GetType(TypeIOReader).String() == param.Type().String() {
-		meta.typ = IOReader
+	if GetType(TypeContext).String() == paramType.String() {
+		typ = Context
 	}
 	return
 }
@@ -143,38 +207,46 @@ func genIds(ids []string) []Code {
 	return results
 }
 
-func (method *Method) resolveCode(file *File) {
+func (method *Method) resolveCode(file *File) (err error) {
 	service := method.service
 	paramList := make([]Code, 0)
 	resultList := make([]Code, 0)
 	params := method.signature.Params()
 	for i := 0; i < params.Len(); i++ {
 		param := params.At(i)
-		paramList = append(paramList, Id(param.Name()).Add(getQual(param.Type().String())))
+		paramList = append(paramList, Id(param.Name()).Add(getTypeQual(param.Type())))
 	}
 
 	results := method.signature.Results()
-	resultList = append(resultList, Id(IdResult).Add(getQual(results.At(0).Type().String())))
+	resultList = append(resultList, Id(IdResult).Add(getTypeQual(results.At(0).Type())))
 	if results.Len() == 2 {
-		resultList = append(resultList, Id(IdError).Add(getQual(results.At(1).Type().String())))
+		resultList = append(resultList, Id(IdError).Add(getTypeQual(results.At(1).Type())))
 	}
 
 	if results.Len() == 3 {
-		resultList = append(resultList, Id(IdStatusCode).Add(getQual(results.At(1).Type().String())))
-		resultList = append(resultList, Id(IdError).Add(getQual(results.At(2).Type().String())))
+		if method.isStream() {
+			resultList = append(resultList, Id(IdStreamErr).Add(getTypeQual(results.At(1).Type())))
+		} else {
+			resultList = append(resultList, Id(IdStatusCode).Add(getTypeQual(results.At(1).Type())))
+		}
+		resultList = append(resultList, Id(IdError).Add(getTypeQual(results.At(2).Type())))
 	}
 
 	file.Func().
-		Params(Id(service.self).Qual(service.pkg, service.implName)).
+		Params(Id(service.self).Qual(service.implPkg, service.implName)).
 		Id(method.Name()).
 		Params(paramList...).Params(resultList...).
-		BlockFunc(method.genMethodBody)
+		BlockFunc(func(group *Group) {
+			err = method.genMethodBody(group)
+		})
 	return
 }
 
-func (method *Method) genMethodBody(group *Group) {
+func (method *Method) genMethodBody(group *Group) (err error) {
 	group.Var().Id(IdBody).Qual(IO, "ReadWriter")
 	group.Var().Id(IdRequest).Op("*").Qual(HttpPkg, "Request")
+	method.genNilStructs(group)
+	method.genFormats(group)
 	if len(method.uri.ids) == 0 {
 		group.Id(IdUri).Op(":=").Lit(method.uri.pattern)
 	} else if method.uri.pattern == StringPlaceholder {
@@ -182,17 +254,25 @@ func (method *Method) genMethodBody(group *Group) {
 	} else {
 		group.Id(IdUri).Op(":=").Qual(FormatPkg, "Sprintf").Call(Lit(method.uri.pattern), List(genIds(method.uri.ids)...))
 	}
-	method.genBody(group)
+	if err = method.genBody(group); err != nil {
+		return
+	}
+	method.compressBody(group)
 	method.genRequest(group)
+	method.addQueryFields(group)
 	// TODO: check @Header, cannot set contentType
 	method.addHeader(group)
 	method.addCookies(group)
 	method.setContentType(group)
+	method.setContentEncoding(group)
+	method.setAccept(group)
+	method.setAcceptEncoding(group)
 	method.genResult(group)
 	group.Return()
+	return
 }
 
-func (method *Method) genBody(group *Group) {
+func (method *Method) genBody(group *Group) (err error) {
 	if len(method.bodyVars) > 0 {
 		switch method.requestType {
 		case JSON:
@@ -200,12 +280,35 @@ func (method *Method) genBody(group *Group) {
 		case XML:
 			method.genJSONOrXMLBody(group, EncodingXML)
 		case Form:
-			method.genFormBody(group)
+			err = method.genFormBody(group)
 		case Multipart:
 			group.Var().Id(IdBodyWriter).Op("*").Qual(MultipartPkg, "Writer")
-			method.genMultipartBody(group)
+			err = method.genMultipartBody(group)
 		}
 	}
+	return
+}
+
+func (method *Method) compressBody(group *Group) {
+	if len(method.bodyVars) > 0 && method.compress != ZeroStr {
+		group.List(Id(IdBody), Id(IdError)).Op("=").Qual(CompressPkg, "Compress").Call(Lit(method.compress), Id(IdBody))
+		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+	}
+}
+
+func (method *Method) setContentEncoding(group *Group) {
+	if len(method.bodyVars) > 0 && method.compress != ZeroStr {
+		group.Id(IdRequest).Dot("Header").
+			Dot("Set").Call(Lit(headers.HeaderContentEncoding), Lit(method.compress))
+	}
+}
+
+// results decoded by generated code accept every encoding of compression.Decompress
+func (method *Method) setAcceptEncoding(group *Group) {
+	if method.resultType != HttpRequest && method.resultType != HttpResponse {
+		group.Id(IdRequest).Dot("Header").
+			Dot("Set").Call(Lit(headers.HeaderAcceptEncoding), Qual(CompressPkg, "AcceptEncoding"))
+	}
 }
 
 func (method *Method) setContentType(group *Group) {
@@ -227,14 +330,20 @@ func (method *Method) setContentType(group *Group) {
 }
 
 func (method *Method) genRequest(group *Group) {
-	group.Id(IdUrl).Op(":=").Qual(StringsPkg, "TrimRight").Call(Id(method.service.self).Dot(FieldBaseUrl), Lit("/")).
-		Op("+").
-		Lit("/").
-		Op("+").
-		Qual(StringsPkg, "TrimLeft").Call(Id(IdUri), Lit("/"))
+	group.Var().Id(IdEndpoint).Op("*").Qual(ClientPkg, "Endpoint")
+	group.List(Id(IdEndpoint), Id(IdError)).Op("=").Id(method.service.self).Dot(FieldBaseUrl).Dot("Pick").Call()
+	group.If(Id(IdError).Op("!=").Nil()).Block(Return())
+	group.Var().Id(IdUrl).Op("*").Qual(NetURL, "URL")
+	group.List(Id(IdUrl), Id(IdError)).Op("=").Id(IdEndpoint).Dot("Resolve").Call(Id(IdUri))
+	group.If(Id(IdError).Op("!=").Nil()).Block(Return())
 
-	group.List(Id(IdRequest), Id(IdError)).Op("=").
-		Qual(HttpPkg, "NewRequest").Call(Lit(method.httpMethod), Id(IdUrl), Id(IdBody))
+	if method.contextId == ZeroStr {
+		group.List(Id(IdRequest), Id(IdError)).Op("=").
+			Qual(HttpPkg, "NewRequest").Call(Lit(method.httpMethod), Id(IdUrl).Dot("String").Call(), Id(IdBody))
+	} else {
+		group.List(Id(IdRequest), Id(IdError)).Op("=").
+			Qual(HttpPkg, "NewRequestWithContext").Call(Id(method.contextId), Lit(method.httpMethod), Id(IdUrl).Dot("String").Call(), Id(IdBody))
+	}
 
 	group.If(Id(IdError).Op("!=").Nil()).Block(Return())
 }
@@ -257,34 +366,90 @@ func (method *Method) addHeader(group *Group) {
 				Qual(FormatPkg, "Sprintf").Call(Lit(pattern.pattern), List(genIds(pattern.ids)...)))
 		}
 	}
+
+	method.addFieldVars(group, HeaderTag, func(key Code, value Code) *Statement {
+		return Id(IdRequest).Dot("Header").Dot("Add").Call(key, value)
+	})
+
+	// per-call headers replace the defaults
+	for _, headerMap := range method.headerMaps {
+		if types.Identical(headerMap.goType.Underlying(), GetType(TypeStringMap)) {
+			group.For(List(Id(IdHeaderKey), Id(IdHeaderValue)).Op(":=").Range().Id(headerMap.key)).Block(
+				Id(IdRequest).Dot("Header").Dot("Set").Call(Id(IdHeaderKey), Id(IdHeaderValue)),
+			)
+		} else {
+			group.For(List(Id(IdHeaderKey), Id(IdHeaderSlice)).Op(":=").Range().Id(headerMap.key)).Block(
+				Id(IdRequest).Dot("Header").Dot("Del").Call(Id(IdHeaderKey)),
+				For(List(Id("_"), Id(IdHeaderValue))).Op(":=").Range().Id(IdHeaderSlice).Block(
+					Id(IdRequest).Dot("Header").Dot("Add").Call(Id(IdHeaderKey), Id(IdHeaderValue)),
+				),
+			)
+		}
+	}
+}
+
+// names of cookies declared by method, by @Cookie or cookie tags
+func (method *Method) cookieNames() (names []string) {
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, cookie := range method.cookieVars {
+		add(cookie.key)
+	}
+	for _, fields := range method.sortedStructVars() {
+		for _, field := range fields {
+			if key, _, ok := field.lookup(CookieTag); ok {
+				add(key)
+			}
+		}
+	}
+	return
 }
 
 func (method *Method) addCookies(group *Group) {
+	// a service cookie is overridden by a method cookie of the same name
+	var addServiceCookie Code = Qual(ClientPkg, "AddCookie").Call(Id(IdRequest), Id(IdCookie))
+	if names := method.cookieNames(); len(names) > 0 {
+		condition := Id(IdCookie).Dot("Name").Op("!=").Lit(names[0])
+		for _, name := range names[1:] {
+			condition = condition.Op("&&").Id(IdCookie).Dot("Name").Op("!=").Lit(name)
+		}
+		addServiceCookie = If(condition).Block(addServiceCookie)
+	}
 	group.For(List(Id("_"), Id(IdCookie))).Op(":=").Range().Id(method.service.self).Dot(FieldCookies).Block(
-		Id(IdRequest).Dot("AddCookie").Call(Id(IdCookie)),
+		addServiceCookie,
 	)
 
-	for _, pattern := range method.cookieVars {
-		if len(pattern.ids) == 0 {
-			group.Id(IdRequest).Dot("AddCookie").Call(
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Lit(pattern.pattern),
-				}),
-			)
-		} else if pattern.pattern == StringPlaceholder {
-			group.Id(IdRequest).Dot("AddCookie").Call(
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Id(pattern.ids[0]),
-				}),
+	for _, cookie := range method.cookieVars {
+		cookie.addTo(group, Id(IdRequest))
+	}
+
+	method.addFieldVars(group, CookieTag, func(key Code, value Code) *Statement {
+		return Id(IdRequest).Dot("AddCookie").Call(
+			Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
+				Id("Name"):  key,
+				Id("Value"): value,
+			}),
+		)
+	})
+
+	for _, cookieMap := range method.cookieMaps {
+		if types.Identical(cookieMap.goType.Underlying(), GetType(TypeStringMap)) {
+			group.For(List(Id(IdCookieName), Id(IdCookieValue)).Op(":=").Range().Id(cookieMap.key)).Block(
+				Id(IdRequest).Dot("AddCookie").Call(
+					Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
+						Id("Name"):  Id(IdCookieName),
+						Id("Value"): Id(IdCookieValue),
+					}),
+				),
 			)
 		} else {
-			group.Id(IdRequest).Dot("AddCookie").Call(
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Qual(FormatPkg, "Sprintf").Call(Lit(pattern.pattern), List(genIds(pattern.ids)...)),
-				}),
+			group.For(List(Id("_"), Id(IdCookie))).Op(":=").Range().Id(cookieMap.key).Block(
+				Id(IdRequest).Dot("AddCookie").Call(Id(IdCookie)),
 			)
 		}
 	}
@@ -295,23 +460,89 @@ func (method *Method) genResult(group *Group) {
 		group.Id(IdResult).Op("=").Id(IdRequest)
 	} else {
 		group.Var().Id(IdResponse).Op("*").Qual(HttpPkg, "Response")
-		group.Id(IdClient).Op(":=").New(Qual(HttpPkg, "Client"))
-		group.List(Id(IdResponse), Id(IdError)).Op("=").Id(IdClient).Dot("Do").Call(Id(IdRequest))
+		group.Id(IdClient).Op(":=").Id(method.service.self).Dot(FieldConfig).Dot("Client").Call()
+		method.withTimeout(group)
+		if method.singleflight {
+			method.wrapDo(group, "Singleflight", nil, method.genCachedDo)
+		} else {
+			method.genCachedDo(group)
+		}
 		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
 		switch method.resultType {
 		case HttpResponse:
 			group.Id(IdResult).Op("=").Id(IdResponse)
-		case JSON:
-			method.unmarshalResult(group, EncodingJSON)
-		case XML:
-			method.unmarshalResult(group, EncodingXML)
-		case HTML:
-			method.unmarshalResult(group, UnHTMLPkg)
+		case JSON, XML, HTML:
+			method.unmarshalResult(group)
+		case SSE:
+			method.streamResult(group, "NewSSEDecoder")
+		case NDJSON:
+			method.streamResult(group, "NewNDJSONDecoder")
 		}
 	}
 }
 
-func (method *Method) unmarshalResult(group *Group, pkg string) {
+// limiter, breaker and the traced call
+func (method *Method) genDo(group *Group) {
+	method.waitLimiter(group)
+	method.allowBreaker(group)
+	method.genCall(group)
+	method.startSpan(group)
+	method.startMeasure(group)
+	group.List(Id(IdResponse), Id(IdError)).Op("=").Id(IdClient).Dot("Do").Call(Id(IdRequest))
+	method.endSpan(group)
+	method.measure(group)
+	group.Id(method.service.self).Dot(FieldBaseUrl).Dot("Report").Call(Id(IdEndpoint), Id(IdResponse), Id(IdError))
+	method.reportBreaker(group)
+	method.observeLimiter(group)
+}
+
+func (method *Method) isStream() bool {
+	return method.resultType == SSE || method.resultType == NDJSON
+}
+
+// decode items in a goroutine as they arrive; the body is closed when the stream ends or the context is done
+func (method *Method) streamResult(group *Group, newDecoder string) {
+	itemType := method.signature.Results().At(0).Type().(*types.Chan).Elem()
+	var newItem, itemRef Code
+	if _, ok := itemType.(*types.Pointer); ok {
+		newItem = Id(IdItem).Op(":=").Add(method.newObject(itemType.String())).Values()
+		itemRef = Id(IdItem)
+	} else {
+		newItem = Var().Id(IdItem).Add(getTypeQual(itemType))
+		itemRef = Op("&").Id(IdItem)
+	}
+
+	group.Id(IdStream).Op(":=").Make(Chan().Add(getTypeQual(itemType)))
+	method.decompressResponse(group)
+	group.Id(IdErrChan).Op(":=").Make(Chan().Error(), Lit(1))
+	group.Id(IdDecoder).Op(":=").Qual(StreamPkg, newDecoder).Call(Id(IdResponse).Dot("Body"))
+	group.Go().Func().Params().Block(
+		Defer().Close(Id(IdErrChan)),
+		Defer().Close(Id(IdStream)),
+		Defer().Id(IdResponse).Dot("Body").Dot("Close").Call(),
+		For().Block(
+			newItem,
+			If(Err().Op(":=").Id(IdDecoder).Dot("Decode").Call(itemRef), Err().Op("!=").Nil()).Block(
+				If(Err().Op("!=").Qual(IO, "EOF")).Block(
+					Id(IdErrChan).Op("<-").Err(),
+				),
+				Return(),
+			),
+			Select().Block(
+				Case(Id(IdStream).Op("<-").Id(IdItem)).Block(),
+				Case(Op("<-").Id(IdRequest).Dot("Context").Call().Dot("Done").Call()).Block(
+					Id(IdErrChan).Op("<-").Id(IdRequest).Dot("Context").Call().Dot("Err").Call(),
+					Return(),
+				),
+			),
+		),
+	).Call()
+	group.Id(IdResult).Op("=").Id(IdStream)
+	group.Id(IdStreamErr).Op("=").Id(IdErrChan)
+}
+
+func (method *Method) unmarshalResult(group *Group) {
+	method.decompressResponse(group)
 	group.Var().Id(IdResultData).Index().Byte()
 	group.List(Id(IdResultData), Id(IdError)).Op("=").
 		Qual(Ioutil, "ReadAll").Call(Id(IdResponse).Dot("Body"))
@@ -319,10 +550,57 @@ func (method *Method) unmarshalResult(group *Group, pkg string) {
 	group.If(Id(IdError).Op("!=").Nil()).Block(Return())
 	group.Id(IdStatusCode).Op("=").Id(IdResponse).Dot("StatusCode")
 	group.Id(IdResult).Op("=").Add(method.newObject(method.signature.Results().At(0).Type().String())).Values()
-	group.Id(IdError).Op("=").Qual(pkg, "Unmarshal").Call(Id(IdResultData), Id(IdResult))
+	if len(method.resultTypes) == 1 {
+		group.Add(unmarshal(method.resultType))
+	} else {
+		// select decoder by Content-Type; the first result type is the default
+		group.List(Id(IdMediaType), Id("_"), Id("_")).Op(":=").
+			Qual(MIMEPkg, "ParseMediaType").Call(Id(IdResponse).Dot("Header").Dot("Get").Call(Lit(headers.HeaderContentType)))
+		group.Switch(Id(IdMediaType)).BlockFunc(func(group *Group) {
+			for _, typ := range method.resultTypes[1:] {
+				cases := make([]Code, 0)
+				for _, mediaType := range typ.mediaTypes() {
+					cases = append(cases, Lit(mediaType))
+				}
+				group.Case(cases...).Block(unmarshal(typ))
+			}
+			group.Default().Block(unmarshal(method.resultType))
+		})
+	}
 	group.If(Id(IdError).Op("!=").Nil()).Block(Return())
 }
 
+func unmarshal(resultType BodyType) Code {
+	pkg := EncodingJSON
+	switch resultType {
+	case XML:
+		pkg = EncodingXML
+	case HTML:
+		pkg = UnHTMLPkg
+	}
+	return Id(IdError).Op("=").Qual(pkg, "Unmarshal").Call(Id(IdResultData), Id(IdResult))
+}
+
+// @Accept overrides; otherwise media types of decoded results unless Accept is set by headers
+func (method *Method) setAccept(group *Group) {
+	if method.accept != ZeroStr {
+		group.Id(IdRequest).Dot("Header").Dot("Set").Call(Lit(headers.HeaderAccept), Lit(method.accept))
+	} else if method.resultType != HttpRequest && method.resultType != HttpResponse {
+		group.If(Id(IdRequest).Dot("Header").Dot("Get").Call(Lit(headers.HeaderAccept)).Op("==").Lit(ZeroStr)).Block(
+			Id(IdRequest).Dot("Header").Dot("Set").Call(Lit(headers.HeaderAccept), Lit(acceptOf(method.resultTypes))),
+		)
+	}
+}
+
+// decode the Content-Encoding of response; the body is closed on failure
+func (method *Method) decompressResponse(group *Group) {
+	group.Id(IdError).Op("=").Qual(CompressPkg, "Decompress").Call(Id(IdResponse))
+	group.If(Id(IdError).Op("!=").Nil()).Block(
+		Id(IdResponse).Dot("Body").Dot("Close").Call(),
+		Return(),
+	)
+}
+
 func (method *Method) newObject(typ string) Code {
 	var statement *Statement
 	var qual *Statement
@@ -351,23 +629,44 @@ func (method *Method) newObject(typ string) Code {
 	return statement
 }
 
-func (method *Method) genFormBody(group *Group) {
+// gen in an if block when the body var is conditional
+func (bodyVar *BodyMeta) guard(group *Group, gen func(group *Group)) {
+	if bodyVar.condition == nil {
+		gen(group)
+	} else {
+		group.If(bodyVar.condition).BlockFunc(gen)
+	}
+}
+
+func (method *Method) genFormBody(group *Group) (err error) {
 	group.Id(IdDataMap).Op(":=").Make(Qual(NetURL, "Values"))
 	for _, bodyVar := range method.bodyVars {
-		switch bodyVar.typ {
-		case TypeInt:
-			fallthrough
-		case TypeString:
-			if len(bodyVar.ids) == 0 {
-				group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Lit(bodyVar.pattern))
-			} else if bodyVar.pattern == StringPlaceholder {
-				group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Id(bodyVar.ids[0]))
-			} else {
-				group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...)))
+		bodyVar.guard(group, func(group *Group) {
+			switch bodyVar.typ {
+			case TypeInt:
+				fallthrough
+			case TypeString:
+				if len(bodyVar.ids) == 0 {
+					group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Lit(bodyVar.pattern))
+				} else if bodyVar.pattern == StringPlaceholder {
+					group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Id(bodyVar.ids[0]))
+				} else {
+					group.Id(IdDataMap).Dot("Add").Call(Lit(bodyVar.key), Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...)))
+				}
+			case Other:
+				var statements []Code
+				statements, err = encodeFormValue(Lit(bodyVar.key), Id(bodyVar.ids[0]), bodyVar.goType, func(key Code, value Code) *Statement {
+					return Id(IdDataMap).Dot("Add").Call(key, value)
+				})
+				addStatements(group, statements)
 			}
+		})
+		if err != nil {
+			return
 		}
 	}
 	group.Id(IdBody).Op("=").Qual(Bytes, "NewBufferString").Call(Id(IdDataMap).Dot("Encode").Call())
+	return
 }
 
 func (method *Method) getIOReaderWriter(bodyVar *BodyMeta) func(group *Group) {
@@ -381,53 +680,46 @@ func (method *Method) getIOReaderWriter(bodyVar *BodyMeta) func(group *Group) {
 	}
 }
 
-func (method *Method) getFileWriter(bodyVar *BodyMeta) func(group *Group) {
-	return func(group *Group) {
-		group.Var().Id(IdPartWriter).Qual(IO, "Writer")
-		group.Var().Id(IdFile).Op("*").Qual(OS, "File")
-		group.Var().Id(IdFilePath).String()
-		if len(bodyVar.ids) == 0 {
-			group.Id(IdFilePath).Op("=").Lit(bodyVar.pattern)
-		} else if bodyVar.pattern == StringPlaceholder {
-			group.Id(IdFilePath).Op("=").Id(bodyVar.ids[0])
-		} else {
-			group.Id(IdFilePath).Op("=").Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...))
-		}
-		group.List(Id(IdFile), Id(IdError)).Op("=").Qual(OS, "Open").Call(Id(IdFilePath))
-		group.Defer().Id(IdFile).Dot("Close").Call()
-		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
-
-		group.List(Id(IdPartWriter), Id(IdError)).Op("=").
-			Id(IdBodyWriter).Dot("CreateFormFile").Call(Lit(bodyVar.key), Id(IdFilePath))
-		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
-
-		group.List(Id("_"), Id(IdError)).Op("=").Qual(IO, "Copy").Call(Id(IdPartWriter), Id(IdFile))
-		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
-	}
+// if genErr = genBodyWriter.WriteField(key, value); genErr != nil { return }
+func writeField(key Code, value Code) *Statement {
+	return If(
+		Id(IdError).Op("=").Id(IdBodyWriter).Dot("WriteField").Call(key, value),
+		Id(IdError).Op("!=").Nil(),
+	).Block(Return())
 }
 
-func (method *Method) genMultipartBody(group *Group) {
+func (method *Method) genMultipartBody(group *Group) (err error) {
 	group.Id(IdBody).Op("=").Qual(Bytes, "NewBufferString").Call(Lit(""))
 	group.Id(IdBodyWriter).Op("=").Qual(MultipartPkg, "NewWriter").Call(Id(IdBody))
 	for _, bodyVar := range method.bodyVars {
-		switch bodyVar.typ {
-		case TypeInt:
-			fallthrough
-		case TypeString:
-			if len(bodyVar.ids) == 0 {
-				group.Id(IdBodyWriter).Dot("WriteField").Call(Lit(bodyVar.key), Lit(bodyVar.pattern))
-			} else if bodyVar.pattern == StringPlaceholder {
-				group.Id(IdBodyWriter).Dot("WriteField").Call(Lit(bodyVar.key), Id(bodyVar.ids[0]))
-			} else {
-				group.Id(IdBodyWriter).Dot("WriteField").Call(Lit(bodyVar.key), Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...)))
+		bodyVar.guard(group, func(group *Group) {
+			switch bodyVar.typ {
+			case TypeInt:
+				fallthrough
+			case TypeString:
+				if len(bodyVar.ids) == 0 {
+					group.Add(writeField(Lit(bodyVar.key), Lit(bodyVar.pattern)))
+				} else if bodyVar.pattern == StringPlaceholder {
+					group.Add(writeField(Lit(bodyVar.key), Id(bodyVar.ids[0])))
+				} else {
+					group.Add(writeField(Lit(bodyVar.key), Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...))))
+				}
+			case IOReader:
+				group.BlockFunc(method.getIOReaderWriter(bodyVar))
+			case TypeFile:
+				group.BlockFunc(method.getFileWriter(bodyVar))
+			case Other:
+				var statements []Code
+				statements, err = encodeFormValue(Lit(bodyVar.key), Id(bodyVar.ids[0]), bodyVar.goType, writeField)
+				addStatements(group, statements)
 			}
-		case IOReader:
-			group.BlockFunc(method.getIOReaderWriter(bodyVar))
-		case TypeFile:
-			group.BlockFunc(method.getFileWriter(bodyVar))
+		})
+		if err != nil {
+			return
 		}
 	}
 	group.Id(IdBodyWriter).Dot("Close").Call()
+	return
 }
 
 func (method *Method) genJSONOrXMLBody(group *Group, pkg string) {
@@ -447,23 +739,25 @@ func (method *Method) genJSONOrXMLBody(group *Group, pkg string) {
 		group.Var().Id(IdData).Index().Byte()
 		group.Id(IdDataMap).Op(":=").Make(Map(String()).Interface())
 		for _, bodyVar := range method.bodyVars {
-			switch bodyVar.typ {
-			case TypeInt:
-				fallthrough
-			case TypeString:
-				if len(bodyVar.ids) == 0 {
-					group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Lit(bodyVar.pattern)
-				} else if bodyVar.pattern == StringPlaceholder {
+			bodyVar.guard(group, func(group *Group) {
+				switch bodyVar.typ {
+				case TypeInt:
+					fallthrough
+				case TypeString:
+					if len(bodyVar.ids) == 0 {
+						group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Lit(bodyVar.pattern)
+					} else if bodyVar.pattern == StringPlaceholder {
+						group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Id(bodyVar.ids[0])
+					} else {
+						group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...))
+					}
+				case IOReader:
+					group.List(Id(IdData), Id(IdError)).Op("=").Qual(Ioutil, "ReadAll").Call(Id(bodyVar.ids[0]))
+					group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").String().Values(Id(IdData))
+				case Other:
 					group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Id(bodyVar.ids[0])
-				} else {
-					group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Qual(FormatPkg, "Sprintf").Call(Lit(bodyVar.pattern), List(genIds(bodyVar.ids)...))
 				}
-			case IOReader:
-				group.List(Id(IdData), Id(IdError)).Op("=").Qual(Ioutil, "ReadAll").Call(Id(bodyVar.ids[0]))
-				group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").String().Values(Id(IdData))
-			case Other:
-				group.Id(IdDataMap).Index(Lit(bodyVar.key)).Op("=").Id(bodyVar.ids[0])
-			}
+			})
 		}
 		group.List(Id(IdData), Id(IdError)).Op("=").Qual(pkg, "Marshal").Call(Id(IdDataMap))
 		group.If(Id(IdError).Op("!=").Nil()).Block(Return())
@@ -472,51 +766,36 @@ func (method *Method) genJSONOrXMLBody(group *Group, pkg string) {
 }
 
 func (method *Method) resolveMetadata() (err error) {
-	err = processor.NewProcessor(method.commentText).Scan(func(ann, key, value string) (err error) {
-		switch ann {
-		case GetAnn:
-			err = method.TrySetMethod(http.MethodGet, value)
-		case HeadAnn:
-			err = method.TrySetMethod(http.MethodHead, value)
-		case PostAnn:
-			err = method.TrySetMethod(http.MethodPost, value)
-		case PutAnn:
-			err = method.TrySetMethod(http.MethodPut, value)
-		case PatchAnn:
-			err = method.TrySetMethod(http.MethodPatch, value)
-		case DeleteAnn:
-			err = method.TrySetMethod(http.MethodDelete, value)
-		case ConnectAnn:
-			err = method.TrySetMethod(http.MethodConnect, value)
-		case OptionsAnn:
-			err = method.TrySetMethod(http.MethodOptions, value)
-		case TraceAnn:
-			err = method.TrySetMethod(http.MethodTrace, value)
-		case BodyAnn:
-			err = method.TrySetBodyType(value)
-		case SingleBodyAnn:
-			err = method.TrySetSingleBodyType(value)
-		case ResultAnn:
-			err = method.TrySetResultType(value)
-		case ParamAnn:
-			err = method.TryAddParam(key, value, TypeString)
-		case HeaderAnn:
-			err = method.TryAddHeader(key, value)
-		case CookieAnn:
-			err = method.TryAddCookie(key, value)
-		case FileAnn:
-			err = method.TryAddParam(key, value, TypeFile)
-		}
-		return
-	})
+	err = method.resolveStructParams()
+	if err == nil {
+		err = processor.NewProcessor(method.commentText).Scan(method.scanAnnotation)
+	}
 
 	if err == nil {
-		method.resolveLeftIds()
-		err = method.checkSingleBody()
+		method.inheritDefaults()
+		method.resolveRequestType()
+		err = method.resolveLeftParams()
+		if err == nil {
+			err = method.checkSingleBody()
+		}
+		if err == nil {
+			err = method.checkFormBody()
+		}
 		if err == nil {
-			method.resolveRequestType()
 			method.resolveUri()
 			err = method.resolveResultType()
+			if err == nil {
+				err = method.resolveCache()
+			}
+			if err == nil {
+				err = method.resolveSingleflight()
+			}
+			if err == nil {
+				err = method.resolveTimeout()
+			}
+			if err == nil {
+				err = method.resolveRetry()
+			}
 			if err == nil {
 				Log.Debugf(`Final URI: "%s".Format(%v...)`, method.uri.pattern, method.uri.ids)
 				Log.Debugf("Final Request Type: %s", method.requestType)
@@ -527,9 +806,114 @@ func (method *Method) resolveMetadata() (err error) {
 	return
 }
 
+func (method *Method) scanAnnotation(ann, key, value string) (err error) {
+	switch ann {
+	case GetAnn:
+		err = method.TrySetMethod(http.MethodGet, value)
+	case HeadAnn:
+		err = method.TrySetMethod(http.MethodHead, value)
+	case PostAnn:
+		err = method.TrySetMethod(http.MethodPost, value)
+	case PutAnn:
+		err = method.TrySetMethod(http.MethodPut, value)
+	case PatchAnn:
+		err = method.TrySetMethod(http.MethodPatch, value)
+	case DeleteAnn:
+		err = method.TrySetMethod(http.MethodDelete, value)
+	case ConnectAnn:
+		err = method.TrySetMethod(http.MethodConnect, value)
+	case OptionsAnn:
+		err = method.TrySetMethod(http.MethodOptions, value)
+	case TraceAnn:
+		err = method.TrySetMethod(http.MethodTrace, value)
+	case BodyAnn:
+		err = method.TrySetBodyType(value)
+	case SingleBodyAnn:
+		err = method.TrySetSingleBodyType(value)
+	case ResultAnn:
+		err = method.TrySetResultType(value)
+	case ParamAnn:
+		err = method.TryAddParam(key, value, TypeString)
+	case HeaderAnn:
+		err = method.TryAddHeader(key, value)
+	case CookieAnn:
+		err = method.TryAddCookie(key, value)
+	case HeadersAnn:
+		err = method.TryAddHeaderMap(value)
+	case CookiesAnn:
+		err = method.TryAddCookieMap(value)
+	case FileAnn:
+		err = method.TryAddFile(key, value)
+	case CompressAnn:
+		err = method.TrySetCompress(value)
+	case TimeoutAnn:
+		err = method.TrySetTimeout(value)
+	case RetryAnn:
+		err = method.TrySetRetry(key, value)
+	case AcceptAnn:
+		err = method.TrySetAccept(value)
+	case CircuitBreakerAnn:
+		err = method.TrySetBreaker(key, value)
+	case RateLimitAnn:
+		err = method.TrySetRateLimit(value)
+	case CacheAnn:
+		err = method.TrySetCache(key, value)
+	case SingleflightAnn:
+		err = method.TrySetSingleflight()
+	case LeftParamsAnn:
+		err = method.TrySetLeftMode(value)
+	}
+	return
+}
+
+// annotations missing on method are inherited from service;
+// a default result type only applies to methods whose results can be decoded by it
+func (method *Method) inheritDefaults() {
+	service := method.service
+	if method.leftMode == ZeroStr && method.requestType != ZeroStr {
+		method.leftMode = LeftBody
+	} else if method.leftMode == ZeroStr {
+		method.leftMode = service.leftMode
+	}
+	if method.requestType == ZeroStr {
+		method.requestType = service.requestType
+	}
+	if method.resultType == ZeroStr && len(service.resultTypes) != 0 && method.acceptResultType(service.resultTypes[0]) {
+		method.resultType, method.resultTypes = service.resultTypes[0], service.resultTypes
+	}
+	if method.accept == ZeroStr {
+		method.accept = service.accept
+	}
+	if method.breaker != nil {
+		method.breakerKey = method.Name()
+	} else if service.breaker != nil {
+		method.breakerKey = service.name
+	}
+	if method.rateLimit != nil {
+		method.limiterKey = method.Name()
+	} else if service.rateLimit != nil {
+		method.limiterKey = service.name
+	}
+	if method.compress == ZeroStr {
+		method.compress = service.compress
+	}
+}
+
+func (method *Method) acceptResultType(resultType BodyType) bool {
+	results := method.signature.Results()
+	if results.Len() != 3 {
+		return false
+	}
+	if _, isChan := results.At(0).Type().(*types.Chan); isChan {
+		return resultType == SSE || resultType == NDJSON
+	}
+	return resultType == JSON || resultType == XML || resultType == HTML
+}
+
 func (method *Method) resolveUri() {
 	if method.uri == nil {
-		method.uri, _ = method.genPatternMeta("uri", "/")
+		method.setRoute("/")
+		method.uri, _ = method.genUriMeta("/")
 	}
 }
 
@@ -544,6 +928,20 @@ func (method *Method) checkSingleBody() (err error) {
 	return
 }
 
+// every form or multipart body var must be form-encodable
+func (method *Method) checkFormBody() (err error) {
+	if method.requestType == Form || method.requestType == Multipart {
+		for _, bodyVar := range method.bodyVars {
+			if bodyVar.typ == Other && err == nil {
+				_, err = encodeFormValue(Lit(bodyVar.key), Id(bodyVar.ids[0]), bodyVar.goType, func(key Code, value Code) *Statement {
+					return Null()
+				})
+			}
+		}
+	}
+	return
+}
+
 func (method *Method) resolveRequestType() {
 	if method.requestType == ZeroStr {
 		method.requestType = JSON
@@ -558,6 +956,7 @@ func (method *Method) resolveResultType() (err error) {
 		if method.resultType == JSON ||
 			method.resultType == XML ||
 			method.resultType == HTML ||
+			method.isStream() ||
 			results.At(0).Type().String() != GetType(TypeRequest).String() &&
 				results.At(0).Type().String() != GetType(TypeResponse).String() ||
 			!types.Identical(results.At(1).Type(), GetType(TypeErr)) {
@@ -572,7 +971,17 @@ func (method *Method) resolveResultType() (err error) {
 			}
 		}
 	case 3:
-		if method.resultType != JSON &&
+		if method.isStream() {
+			if chanType, ok := results.At(0).Type().(*types.Chan); !ok ||
+				chanType.Dir() == types.SendOnly ||
+				!types.Identical(results.At(1).Type(), GetType(TypeErrChan)) ||
+				!types.Identical(results.At(2).Type(), GetType(TypeErr)) {
+				err = ConflictAnnotationError(ResultAnn, results)
+			} else if method.contextId == ZeroStr {
+				// the decoder goroutine stops only when the context is done
+				err = errors.New(StreamWithoutContext)
+			}
+		} else if method.resultType != JSON &&
 			method.resultType != XML &&
 			method.resultType != HTML &&
 			method.resultType != ZeroStr ||
@@ -581,7 +990,7 @@ func (method *Method) resolveResultType() (err error) {
 			err = ConflictAnnotationError(ResultAnn, results)
 		}
 		if err == nil && method.resultType == ZeroStr {
-			method.resultType = JSON
+			method.resultType, method.resultTypes = JSON, []BodyType{JSON}
 		}
 	default:
 		err = ConflictAnnotationError(ResultAnn, results)
@@ -591,7 +1000,9 @@ func (method *Method) resolveResultType() (err error) {
 
 func (meta *MethodMeta) resolveLeftIds() {
 	for id := range meta.idList {
-		if paramMeta, exist := meta.totalIds[id]; exist {
+		if fields, isStruct := meta.structVars[id]; isStruct {
+			meta.resolveStructBodyVars(fields)
+		} else if paramMeta, exist := meta.totalIds[id]; exist {
 			Log.Debugf("Set Param(%s) <- %s", paramMeta.key, id)
 			patternMeta := &PatternMeta{key: paramMeta.key, ids: []string{id}}
 			switch paramMeta.typ {
@@ -600,7 +1011,7 @@ func (meta *MethodMeta) resolveLeftIds() {
 			case TypeInt:
 				patternMeta.pattern = IntPlaceholder
 			}
-			bodyMeta := &BodyMeta{patternMeta, paramMeta.typ}
+			bodyMeta := &BodyMeta{PatternMeta: patternMeta, typ: paramMeta.typ, goType: paramMeta.goType}
 			meta.bodyVars = append(meta.bodyVars, bodyMeta)
 		} else {
 			log.Fatal(IdNotExistError(id))
@@ -617,9 +1028,64 @@ func (meta *MethodMeta) TryAddHeader(key, value string) (err error) {
 }
 
 func (meta *MethodMeta) TryAddCookie(key, value string) (err error) {
+	var name string
+	var attributes Dict
 	var patternMeta *PatternMeta
-	patternMeta, err = meta.genPatternMeta(key, value)
-	meta.headerVars = append(meta.cookieVars, patternMeta)
+	name, attributes, err = parseCookieKey(key)
+	if err == nil {
+		patternMeta, err = meta.genPatternMeta(name, value)
+	}
+	if err == nil {
+		Log.Debugf("Add Cookie: %s(%s)", key, value)
+		meta.cookieVars = append(meta.cookieVars, &CookieMeta{PatternMeta: patternMeta, attributes: attributes})
+	}
+	return
+}
+
+func (meta *MethodMeta) TryAddHeaderMap(value string) (err error) {
+	var paramMeta *ParamMeta
+	paramMeta, err = meta.getMapParam(HeadersAnn, value)
+	if err == nil {
+		if types.Identical(paramMeta.goType.Underlying(), GetType(TypeHeader).Underlying()) ||
+			types.Identical(paramMeta.goType.Underlying(), GetType(TypeStringMap)) {
+			Log.Debugf("Add Headers: %s", paramMeta.key)
+			meta.headerMaps = append(meta.headerMaps, paramMeta)
+		} else {
+			err = HeadersTypeUnsupportedError(paramMeta.key)
+		}
+	}
+	return
+}
+
+func (meta *MethodMeta) TryAddCookieMap(value string) (err error) {
+	var paramMeta *ParamMeta
+	paramMeta, err = meta.getMapParam(CookiesAnn, value)
+	if err == nil {
+		if paramMeta.goType.String() == GetType(TypeCookies).String() ||
+			types.Identical(paramMeta.goType.Underlying(), GetType(TypeStringMap)) {
+			Log.Debugf("Add Cookies: %s", paramMeta.key)
+			meta.cookieMaps = append(meta.cookieMaps, paramMeta)
+		} else {
+			err = CookiesTypeUnsupportedError(paramMeta.key)
+		}
+	}
+	return
+}
+
+// value must be a single {param}, which is used up
+func (meta *MethodMeta) getMapParam(ann, value string) (paramMeta *ParamMeta, err error) {
+	if IdRe.FindString(value) != value || value == ZeroStr {
+		err = UnsupportedAnnotationValueError(ann, value)
+	}
+	if err == nil {
+		id := getIdFromPattern(value)
+		var exist bool
+		if paramMeta, exist = meta.totalIds[id]; !exist {
+			err = IdNotExistError(id)
+		} else {
+			meta.idList.deleteKey(id)
+		}
+	}
 	return
 }
 
@@ -627,12 +1093,28 @@ func (meta *MethodMeta) TryAddParam(key, pattern string, typ ParamType) (err err
 	patternMeta, err := meta.genPatternMeta(key, pattern)
 	if err == nil {
 		Log.Debugf("Set Param(%s) %s", patternMeta.key, pattern)
-		meta.bodyVars = append(meta.bodyVars, &BodyMeta{patternMeta, typ})
+		meta.bodyVars = append(meta.bodyVars, &BodyMeta{PatternMeta: patternMeta, typ: typ})
 	}
 	return
 }
 
 func (meta *MethodMeta) genPatternMeta(key, pattern string) (patternMeta *PatternMeta, err error) {
+	return meta.genEscapedPatternMeta(key, pattern, nil)
+}
+
+// formatted values are escaped by url.PathEscape before '?' and by url.QueryEscape after it
+func (meta *MethodMeta) genUriMeta(pattern string) (patternMeta *PatternMeta, err error) {
+	query := strings.Index(pattern, "?")
+	return meta.genEscapedPatternMeta("uri", pattern, func(offset int) string {
+		if query >= 0 && offset > query {
+			return "QueryEscape"
+		}
+		return "PathEscape"
+	})
+}
+
+// escape gives the url func escaping formatted values at an offset of pattern; nil if unescaped
+func (meta *MethodMeta) genEscapedPatternMeta(key, pattern string, escape func(offset int) string) (patternMeta *PatternMeta, err error) {
 	// TODO: can be empty?
 	if key == ZeroStr {
 		err = errors.New(PatternKeyMustNotBeEmpty)
@@ -643,17 +1125,25 @@ func (meta *MethodMeta) genPatternMeta(key, pattern string) (patternMeta *Patter
 			key: key,
 			ids: make([]string, 0),
 		}
-		patterns := IdRe.FindAllString(pattern, -1)
-		for _, pattern := range patterns {
-			id := getIdFromPattern(pattern)
-			if err = meta.checkPattern(id); err != nil {
+		placeholders := make([]string, 0)
+		for _, loc := range IdRe.FindAllStringIndex(pattern, -1) {
+			var placeholder, arg, escapeFunc string
+			id, format := splitPattern(pattern[loc[0]:loc[1]])
+			if escape != nil {
+				escapeFunc = escape(loc[0])
+			}
+			if placeholder, arg, err = meta.formatId(id, format, escapeFunc); err != nil {
 				break
 			}
 			meta.idList.deleteKey(id)
-			patternMeta.ids = append(patternMeta.ids, id)
+			placeholders = append(placeholders, placeholder)
+			patternMeta.ids = append(patternMeta.ids, arg)
 		}
 		if err == nil {
-			patternMeta.pattern = IdRe.ReplaceAllStringFunc(pattern, meta.findAndReplace)
+			patternMeta.pattern = IdRe.ReplaceAllStringFunc(pattern, func(string) (placeholder string) {
+				placeholder, placeholders = placeholders[0], placeholders[1:]
+				return
+			})
 		}
 	}
 	return
@@ -675,10 +1165,9 @@ func (meta *MethodMeta) TrySetBodyType(value string) (err error) {
 
 func (meta *MethodMeta) TrySetResultType(value string) (err error) {
 	if meta.resultType == ZeroStr {
-		if value == JSON || value == XML || value == HTML {
-			meta.resultType = BodyType(value)
-		} else {
-			err = UnsupportedAnnotationValueError(ResultAnn, value)
+		meta.resultTypes, err = parseResultTypes(value)
+		if err == nil {
+			meta.resultType = meta.resultTypes[0]
 		}
 	} else {
 		err = DuplicatedAnnotationError(ResultAnn)
@@ -686,6 +1175,29 @@ func (meta *MethodMeta) TrySetResultType(value string) (err error) {
 	return
 }
 
+func (meta *MethodMeta) TrySetAccept(value string) (err error) {
+	if meta.accept == ZeroStr {
+		Log.Debugf("Set Accept: %s", value)
+		meta.accept = value
+	} else {
+		err = DuplicatedAnnotationError(AcceptAnn)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetCompress(value string) (err error) {
+	if meta.compress != ZeroStr {
+		err = DuplicatedAnnotationError(CompressAnn)
+	} else if !compression.IsSupported(value) {
+		err = UnsupportedAnnotationValueError(CompressAnn, value)
+	}
+	if err == nil {
+		Log.Debugf("Set Compress: %s", value)
+		meta.compress = value
+	}
+	return
+}
+
 func (meta *MethodMeta) TrySetSingleBodyType(value string) (err error) {
 	if meta.requestType == ZeroStr {
 		if value == JSON || value == XML {
@@ -707,10 +1219,12 @@ func (meta *MethodMeta) TrySetMethod(httpMethod, uriPattern string) (err error)
 	}
 
 	if err == nil {
-		_, err = url.Parse(uriPattern)
+		// formats such as {n:%.2f} are not valid escapes
+		_, err = url.Parse(stripFormats(uriPattern))
 		if err == nil {
 			meta.httpMethod = httpMethod
-			meta.uri, err = meta.genPatternMeta("uri", uriPattern)
+			meta.setRoute(uriPattern)
+			meta.uri, err = meta.genUriMeta(uriPattern)
 			if err == nil {
 				Log.Debugf("Set Method: %s(%s)", httpMethod, uriPattern)
 			}
@@ -720,30 +1234,15 @@ func (meta *MethodMeta) TrySetMethod(httpMethod, uriPattern string) (err error)
 }
 
 func getIdFromPattern(pattern string) string {
-	return strings.TrimRight(strings.TrimLeft(pattern, "{"), "}")
+	id, _ := splitPattern(pattern)
+	return id
 }
 
-func (meta *MethodMeta) findAndReplace(pattern string) (placeholder string) {
-	id := getIdFromPattern(pattern)
-	paramMeta := meta.totalIds[id]
-	switch paramMeta.typ {
-	case TypeString:
-		placeholder = StringPlaceholder
-	case TypeInt:
-		placeholder = IntPlaceholder
-	default:
-		log.Fatal(PatternIdTypeMustBeIntOrStringError(id))
-	}
-	return
-}
-
-func (meta *MethodMeta) checkPattern(id string) (err error) {
-	if paramMeta, exist := meta.totalIds[id]; exist {
-		if paramMeta.typ != TypeString && paramMeta.typ != TypeInt {
-			err = PatternIdTypeMustBeIntOrStringError(id)
-		}
-	} else {
-		err = IdNotExistError(id)
+// {id:format} -> id, format
+func splitPattern(pattern string) (id, format string) {
+	id = strings.TrimRight(strings.TrimLeft(pattern, "{"), "}")
+	if index := strings.Index(id, ":"); index >= 0 {
+		id, format = id[:index], id[index+1:]
 	}
 	return
 }
@@ -0,0 +1,19 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+const (
+	IdStart = "genStart"
+)
+
+func (method *Method) startMeasure(group *Group) {
+	group.Id(IdStart).Op(":=").Qual(TimePkg, "Now").Call()
+}
+
+// measured and logged when the response body is closed, so reading the body counts in the duration
+func (method *Method) measure(group *Group) {
+	group.Id(method.service.self).Dot(FieldConfig).Dot("Measure").Call(Id(IdCall), Id(IdRequest), Id(IdStart), Id(IdResponse), Id(IdError))
+	group.Id(method.service.self).Dot(FieldConfig).Dot("Log").Call(Id(IdCall), Id(IdRequest), Id(IdStart), Id(IdResponse), Id(IdError))
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"fmt"
+	. "github.com/dave/jennifer/jen"
+	. "github.com/rady-io/http-service/log"
+	"go/types"
+	"time"
+)
+
+const (
+	// fields of a mock. etc. GetItemFunc
+	MockFuncSuffix = "Func"
+	MockSuffix     = "Mock"
+
+	IdMock = "genMock"
+)
+
+// Mock generates <Service>Mock in package outPkg, whose methods call the func fields of the same names;
+// an empty outName is guessed from outPkg
+func Mock(service *Service, pkg, outPkg, outName string) (code string, err error) {
+	Log.Infof("Mock Service: %s", service.name)
+	service.prepare(pkg, outPkg)
+	if service.service == nil {
+		err = ServiceNotFoundError(service.name)
+		return
+	}
+	file := NewFilePath(outPkg)
+	if outName != ZeroStr {
+		file = NewFilePathName(outPkg, outName)
+	}
+	service.genMock(file)
+	code, err = render(file)
+	return
+}
+
+func (srv *Service) genMock(file *File) {
+	mockName := srv.name + MockSuffix
+	file.HeaderComment(fmt.Sprintf(`Mock of %s.%s
+This file is generated by github.com/Hexilee/impler at %s
+DON'T EDIT IT!
+`, srv.pkg, srv.name, time.Now()))
+
+	methods := srv.mockMethods()
+	fields := make([]Code, 0, len(methods))
+	for _, method := range methods {
+		fields = append(fields, Id(method.Name()+MockFuncSuffix).Func().Add(signatureCode(method.Type().(*types.Signature), false)))
+	}
+	file.Comment(fmt.Sprintf("%s implements %s by func fields; calling a method whose func is nil panics", mockName, srv.name))
+	file.Type().Id(mockName).Struct(fields...)
+	file.Var().Id("_").Qual(srv.pkg, srv.name).Op("=").Op("&").Id(mockName).Values()
+
+	for _, method := range methods {
+		signature := method.Type().(*types.Signature)
+		funcName := method.Name() + MockFuncSuffix
+		args := make([]Code, 0, signature.Params().Len())
+		for i := 0; i < signature.Params().Len(); i++ {
+			arg := Id(mockParamName(signature, i))
+			if signature.Variadic() && i == signature.Params().Len()-1 {
+				arg = arg.Op("...")
+			}
+			args = append(args, arg)
+		}
+		call := Id(IdMock).Dot(funcName).Call(args...)
+		file.Func().Params(Id(IdMock).Op("*").Id(mockName)).Id(method.Name()).Add(signatureCode(signature, true)).Block(
+			If(Id(IdMock).Dot(funcName).Op("==").Nil()).Block(
+				Panic(Lit(mockName+"."+funcName+" is nil")),
+			),
+			func() Code {
+				if signature.Results().Len() == 0 {
+					return call
+				}
+				return Return(call)
+			}(),
+		)
+	}
+}
+
+// explicit methods and methods of embedded interfaces, generated ones included
+func (srv *Service) mockMethods() []*types.Func {
+	methods := make([]*types.Func, 0, srv.service.NumMethods())
+	for i := 0; i < srv.service.NumMethods(); i++ {
+		methods = append(methods, srv.service.Method(i))
+	}
+	return methods
+}
+
+// (params) (results); params are named if named is true
+func signatureCode(signature *types.Signature, named bool) *Statement {
+	params := make([]Code, 0, signature.Params().Len())
+	for i := 0; i < signature.Params().Len(); i++ {
+		var typ Code = getTypeQual(signature.Params().At(i).Type())
+		if signature.Variadic() && i == signature.Params().Len()-1 {
+			typ = Op("...").Add(getTypeQual(signature.Params().At(i).Type().(*types.Slice).Elem()))
+		}
+		if named {
+			params = append(params, Id(mockParamName(signature, i)).Add(typ))
+		} else {
+			params = append(params, typ)
+		}
+	}
+	results := make([]Code, 0, signature.Results().Len())
+	for i := 0; i < signature.Results().Len(); i++ {
+		results = append(results, getTypeQual(signature.Results().At(i).Type()))
+	}
+	statement := Params(params...)
+	switch len(results) {
+	case 0:
+	case 1:
+		statement = statement.Add(results[0])
+	default:
+		statement = statement.Parens(List(results...))
+	}
+	return statement
+}
+
+// unnamed and blank params are named by position
+func mockParamName(signature *types.Signature, i int) string {
+	name := signature.Params().At(i).Name()
+	if name == ZeroStr || name == "_" {
+		name = fmt.Sprintf("p%d", i)
+	}
+	return name
+}
@@ -0,0 +1,194 @@
+package impl
+
+import (
+	"encoding/json"
+	. "github.com/rady-io/http-service/log"
+	"net/http"
+	"strings"
+)
+
+const (
+	OpenAPIVersion = "3.0.3"
+)
+
+type (
+	// a minimal OpenAPI document; bodies are described by media types only
+	OpenAPIDoc struct {
+		OpenAPI string                                  `json:"openapi"`
+		Info    OpenAPIInfo                             `json:"info"`
+		Servers []*OpenAPIServer                        `json:"servers,omitempty"`
+		Paths   map[string]map[string]*OpenAPIOperation `json:"paths"`
+	}
+
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	OpenAPIServer struct {
+		URL       string                            `json:"url"`
+		Variables map[string]*OpenAPIServerVariable `json:"variables,omitempty"`
+	}
+
+	OpenAPIServerVariable struct {
+		Default string `json:"default"`
+	}
+
+	OpenAPIOperation struct {
+		OperationId string                      `json:"operationId"`
+		Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenAPIBody                `json:"requestBody,omitempty"`
+		Responses   map[string]*OpenAPIResponse `json:"responses"`
+	}
+
+	OpenAPIParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"`
+		Required bool           `json:"required,omitempty"`
+		Schema   *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPIBody struct {
+		Content map[string]*OpenAPIMediaType `json:"content"`
+	}
+
+	OpenAPIResponse struct {
+		Description string                       `json:"description"`
+		Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema"`
+	}
+
+	OpenAPISchema struct {
+		Type string `json:"type,omitempty"`
+	}
+)
+
+// OpenAPI describes the routes of service as an indented JSON document
+func OpenAPI(service *Service, pkg string) (doc []byte, err error) {
+	Log.Infof("Describe Service: %s", service.name)
+	service.prepare(pkg, pkg)
+	if err = service.resolveMetadata(); err == nil {
+		err = service.resolveMethods()
+	}
+	if err == nil {
+		doc, err = json.MarshalIndent(service.openAPIDoc(), ZeroStr, "  ")
+	}
+	return
+}
+
+func (srv *Service) openAPIDoc() *OpenAPIDoc {
+	doc := &OpenAPIDoc{
+		OpenAPI: OpenAPIVersion,
+		Info:    OpenAPIInfo{Title: srv.name, Version: "0.0.0"},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	for _, baseUrl := range srv.baseUrls {
+		server := &OpenAPIServer{URL: baseUrl.template()}
+		for _, id := range baseUrl.ids {
+			if server.Variables == nil {
+				server.Variables = make(map[string]*OpenAPIServerVariable)
+			}
+			server.Variables[id] = &OpenAPIServerVariable{Default: ZeroStr}
+		}
+		doc.Servers = append(doc.Servers, server)
+	}
+	for _, method := range srv.sortedMethods() {
+		operations, ok := doc.Paths[method.route]
+		if !ok {
+			operations = make(map[string]*OpenAPIOperation)
+			doc.Paths[method.route] = operations
+		}
+		operations[strings.ToLower(method.getHttpMethod())] = method.openAPIOperation()
+	}
+	return doc
+}
+
+func (method *Method) openAPIOperation() *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationId: method.Name(),
+		Parameters:  make([]*OpenAPIParameter, 0),
+		Responses:   map[string]*OpenAPIResponse{"default": {Description: "response of " + method.Name()}},
+	}
+	for _, pattern := range IdRe.FindAllString(method.route, -1) {
+		id := getIdFromPattern(pattern)
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name: id, In: "path", Required: true, Schema: method.totalIds[id].schema(),
+		})
+	}
+	if query := strings.SplitN(method.uri.pattern, "?", 2); len(query) == 2 {
+		for _, pair := range strings.Split(query[1], "&") {
+			if key := strings.SplitN(pair, "=", 2)[0]; key != ZeroStr {
+				operation.Parameters = append(operation.Parameters, &OpenAPIParameter{Name: key, In: "query", Schema: &OpenAPISchema{Type: "string"}})
+			}
+		}
+	}
+	for _, queryVar := range method.queryVars {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{Name: queryVar.key, In: "query", Schema: &OpenAPISchema{Type: "string"}})
+	}
+	for _, pattern := range method.headerVars {
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{Name: pattern.key, In: "header", Schema: &OpenAPISchema{Type: "string"}})
+	}
+	for _, in := range []string{QueryTag, HeaderTag, CookieTag} {
+		for _, fields := range method.sortedStructVars() {
+			for _, field := range fields {
+				if key, _, ok := field.lookup(in); ok {
+					operation.Parameters = append(operation.Parameters, &OpenAPIParameter{Name: key, In: in, Schema: &OpenAPISchema{Type: "string"}})
+				}
+			}
+		}
+	}
+
+	if len(method.bodyVars) != 0 {
+		operation.RequestBody = &OpenAPIBody{Content: map[string]*OpenAPIMediaType{
+			method.requestType.contentType(): {Schema: &OpenAPISchema{Type: "object"}},
+		}}
+	}
+	if len(method.resultTypes) != 0 {
+		content := make(map[string]*OpenAPIMediaType)
+		for _, resultType := range method.resultTypes {
+			for _, mediaType := range resultType.mediaTypes() {
+				content[mediaType] = &OpenAPIMediaType{Schema: &OpenAPISchema{}}
+			}
+		}
+		operation.Responses["default"].Content = content
+	}
+	return operation
+}
+
+func (param *ParamMeta) schema() *OpenAPISchema {
+	if param.typ == TypeInt {
+		return &OpenAPISchema{Type: "integer"}
+	}
+	return &OpenAPISchema{Type: "string"}
+}
+
+// the pattern with placeholders replaced by {id}
+func (pattern *PatternMeta) template() string {
+	template := new(strings.Builder)
+	ids := pattern.ids
+	for i := 0; i < len(pattern.pattern); i++ {
+		if pattern.pattern[i] == '%' && i+1 < len(pattern.pattern) {
+			i++
+			if pattern.pattern[i] == '%' || len(ids) == 0 {
+				template.WriteByte(pattern.pattern[i])
+			} else {
+				template.WriteString("{" + ids[0] + "}")
+				ids = ids[1:]
+			}
+			continue
+		}
+		template.WriteByte(pattern.pattern[i])
+	}
+	return template.String()
+}
+
+func (method *Method) getHttpMethod() string {
+	if method.httpMethod == ZeroStr {
+		return http.MethodGet
+	}
+	return method.httpMethod
+}
@@ -0,0 +1,11 @@
+package impl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPatternMeta_Template(t *testing.T) {
+	pattern := &PatternMeta{pattern: "%s://box.zjuqsc.com/item/%d?rate=100%%", ids: []string{"scheme", "id"}}
+	assert.Equal(t, "{scheme}://box.zjuqsc.com/item/{id}?rate=100%", pattern.template())
+}
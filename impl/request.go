@@ -5,6 +5,7 @@ const (
 	TypeString
 	IOReader
 	TypeFile
+	Context
 	Other
 )
 
@@ -0,0 +1,23 @@
+package impl
+
+// generated methods with the signature checker of their declarations
+var reservedMethods = map[string]func(srv *Service, method *Method) bool{
+	SetBaseURLMethod:  (*Service).isBaseUrlMethod,
+	WithBaseURLMethod: (*Service).isBaseUrlMethod,
+	BreakersMethod:    (*Service).isBreakersMethod,
+	CurlMethod:        (*Service).isCurlMethod,
+}
+
+// reserved methods declared in the service interface are implemented by the generated ones, not by annotations
+func (srv *Service) resolveReservedMethods() (err error) {
+	for pos, method := range srv.methods {
+		if isReserved, ok := reservedMethods[method.Name()]; ok {
+			if !isReserved(srv, method) {
+				err = ReservedMethodError(method.Name())
+				break
+			}
+			delete(srv.methods, pos)
+		}
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// options of @Retry. etc. @Retry(attempts=3, backoff=100ms)
+	AttemptsOption = "attempts"
+	BackoffOption  = "backoff"
+
+	DefaultRetryAttempts = 3
+)
+
+type (
+	RetryMeta struct {
+		attempts int           // in total, including the first one
+		backoff  time.Duration // before each retry
+	}
+)
+
+// options in key, or in value if the annotation has no key
+func parseRetry(key, value string) (retry *RetryMeta, err error) {
+	retry = &RetryMeta{attempts: DefaultRetryAttempts}
+	options := key
+	if options == ZeroStr {
+		options = value
+	}
+	for _, option := range strings.Split(options, ",") {
+		option = strings.TrimSpace(option)
+		if option == ZeroStr {
+			continue
+		}
+		equal := strings.Index(option, "=")
+		if equal == -1 {
+			err = UnsupportedAnnotationValueError(RetryAnn, option)
+			break
+		}
+		optionKey, optionValue := strings.TrimSpace(option[:equal]), strings.TrimSpace(option[equal+1:])
+		switch optionKey {
+		case AttemptsOption:
+			retry.attempts, err = strconv.Atoi(optionValue)
+		case BackoffOption:
+			retry.backoff, err = time.ParseDuration(optionValue)
+		default:
+			err = UnsupportedAnnotationValueError(RetryAnn, option)
+		}
+		if err != nil || retry.attempts <= 0 || retry.backoff < 0 {
+			err = UnsupportedAnnotationValueError(RetryAnn, option)
+			break
+		}
+	}
+	if err != nil {
+		retry = nil
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetRetry(key, value string) (err error) {
+	if meta.retry != nil {
+		err = DuplicatedAnnotationError(RetryAnn)
+	}
+	if err == nil {
+		meta.retry, err = parseRetry(key, value)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetRetry(key, value string) (err error) {
+	if meta.retry != nil {
+		err = DuplicatedAnnotationError(RetryAnn)
+	}
+	if err == nil {
+		meta.retry, err = parseRetry(key, value)
+	}
+	return
+}
+
+// the service retry applies to idempotent http methods only; a method retry needs a call to retry
+func (method *Method) resolveRetry() (err error) {
+	if method.retry == nil && method.replayable() {
+		method.retry = method.service.retry
+	} else if method.retry != nil && method.resultType == HttpRequest {
+		err = ConflictAnnotationError(RetryAnn, method.signature)
+	}
+	return
+}
+
+// GET, HEAD, OPTIONS, PUT or DELETE, which can be sent twice
+func (method *Method) replayable() bool {
+	switch method.httpMethod {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return method.resultType != HttpRequest
+	}
+	return false
+}
+
+// genResponse, genErr = client.Retry{...}.Do(service.baseUrl, genEndpoint, genUri, genRequest, func(genEndpoint, genRequest) (...) { genDo })
+func (method *Method) genRetriedDo(group *Group) {
+	if method.retry == nil {
+		method.genDo(group)
+		return
+	}
+	retry := Dict{Id("Attempts"): Lit(method.retry.attempts)}
+	if method.retry.backoff > 0 {
+		retry[Id("Backoff")] = durationCode(method.retry.backoff)
+	}
+	group.List(Id(IdResponse), Id(IdError)).Op("=").Qual(ClientPkg, "Retry").Values(retry).Dot("Do").Call(
+		Id(method.service.self).Dot(FieldBaseUrl), Id(IdEndpoint), Id(IdUri), Id(IdRequest),
+		Func().
+			Params(Id(IdEndpoint).Op("*").Qual(ClientPkg, "Endpoint"), Id(IdRequest).Op("*").Qual(HttpPkg, "Request")).
+			Params(Id(IdResponse).Op("*").Qual(HttpPkg, "Response"), Id(IdError).Error()).
+			BlockFunc(func(group *Group) {
+				method.genDo(group)
+				group.Return()
+			}),
+	)
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	. "github.com/dave/jennifer/jen"
 	"github.com/rady-io/annotation-processor"
+	"github.com/rady-io/http-service/compression"
 	. "github.com/rady-io/http-service/log"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"sort"
 	"strings"
 	"time"
 )
@@ -26,7 +28,7 @@ func NewService(name string, info *types.Info) *Service {
 		ServiceMeta: &ServiceMeta{
 			idList:     make([]string, 0),
 			headerVars: make([]*PatternMeta, 0),
-			cookieVars: make([]*PatternMeta, 0),
+			cookieVars: make([]*CookieMeta, 0),
 		},
 	}
 }
@@ -37,53 +39,105 @@ type (
 		methods     map[token.Pos]*Method
 		name        string
 		commentText string
+		docs        []*ast.CommentGroup // comments of the declaration and the type spec, for positions
+		pos         token.Pos           // name of the type spec
 		service     *types.Interface
 		*ServiceMeta
 	}
 
 	ServiceMeta struct {
 		idList                       []string
-		baseUrl                      *PatternMeta
+		baseUrls                     []*PatternMeta // endpoints balanced by client.BaseURL
+		compress                     string
+		requestType                  BodyType // defaults of methods
+		resultTypes                  []BodyType
+		accept                       string
+		breaker                      *BreakerMeta // shared by methods without their own
+		rateLimit                    *RateLimitMeta
+		cache                        *CacheMeta
+		singleflight                 bool
+		timeout                      time.Duration
+		retry                        *RetryMeta
+		leftMode                     string // @LeftParams
 		headerVars                   []*PatternMeta
-		cookieVars                   []*PatternMeta
+		cookieVars                   []*CookieMeta
 		self, pkg, implName, newFunc string
+		implPkg                      string // package of the generated file; pkg of the interface
 	}
 )
 
 func (srv *Service) resolveCode(file *File) (err error) {
+	if err = srv.resolveMethods(); err != nil {
+		return
+	}
+
 	file.HeaderComment(fmt.Sprintf(`Implement of %s.%s
 This file is generated by github.com/Hexilee/impler at %s
 DON'T EDIT IT!
 `, srv.pkg, srv.name, time.Now()))
 	file.Func().Id(srv.newFunc).Params(srv.getParams()).Qual(srv.pkg, srv.name).BlockFunc(func(group *Group) {
 		group.Id(srv.self).Op(":=").Op("&").Id(srv.implName).Values(Dict{
-			Id(FieldHeader):  Make(Qual(HttpPkg, "Header")),
-			Id(FieldCookies): Make(Index().Op("*").Qual(HttpPkg, "Cookie"), Lit(0)),
+			Id(FieldHeader):   Make(Qual(HttpPkg, "Header")),
+			Id(FieldCookies):  Make(Index().Op("*").Qual(HttpPkg, "Cookie"), Lit(0)),
+			Id(FieldConfig):   Qual(ClientPkg, "NewConfig").Call(Id(IdOptions).Op("...")),
+			Id(FieldBreakers): Make(Map(String()).Op("*").Qual(ClientPkg, "Breaker")),
+			Id(FieldLimiters): Make(Map(String()).Op("*").Qual(ClientPkg, "Limiter")),
 		})
 		srv.setBaseUrl(group)
 		srv.addHeader(group)
 		srv.addCookies(group)
+		srv.genBreakers(group)
+		srv.genLimiters(group)
 		group.Return(Id(srv.self))
 
 	})
 
 	file.Type().Id(srv.implName).Struct(
-		Id(FieldBaseUrl).String(),
+		Id(FieldBaseUrl).Op("*").Qual(ClientPkg, "BaseURL"),
 		Id(FieldHeader).Qual(HttpPkg, "Header"),
 		Id(FieldCookies).Index().Op("*").Qual(HttpPkg, "Cookie"),
+		Id(FieldConfig).Op("*").Qual(ClientPkg, "Config"),
+		Id(FieldBreakers).Map(String()).Op("*").Qual(ClientPkg, "Breaker"),
+		Id(FieldLimiters).Map(String()).Op("*").Qual(ClientPkg, "Limiter"),
 	)
 
-	for _, method := range srv.methods {
+	srv.genBaseUrlMethods(file)
+	srv.genBreakersMethod(file)
+	srv.genCurlMethod(file)
+
+	for _, method := range srv.sortedMethods() {
+		if err = method.resolveCode(file); err != nil {
+			err = MethodError(srv.name, method.Name(), err)
+			return
+		}
+	}
+	return
+}
+
+func (srv *Service) resolveMethods() (err error) {
+	for _, method := range srv.sortedMethods() {
 		Log.Infof("Implement method: %s", method.String())
 		err = method.resolveMetadata()
 		if err != nil {
-			break
+			err = MethodError(srv.name, method.Name(), err)
+			return
 		}
-		method.resolveCode(file)
 	}
 	return
 }
 
+// methods in the order of declaration, for a stable output
+func (srv *Service) sortedMethods() []*Method {
+	methods := make([]*Method, 0, len(srv.methods))
+	for _, method := range srv.methods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Pos() < methods[j].Pos()
+	})
+	return methods
+}
+
 func (srv *Service) getParams() (params Code) {
 	paramList := make([]Code, 0)
 	for _, id := range srv.idList {
@@ -91,23 +145,20 @@ func (srv *Service) getParams() (params Code) {
 	}
 
 	if len(paramList) == 0 {
-		params = List(paramList...)
+		params = Id(IdOptions).Op("...").Qual(ClientPkg, "Option")
 	} else {
-		params = List(paramList...).Add(String())
+		params = List(List(paramList...).Add(String()), Id(IdOptions).Op("...").Qual(ClientPkg, "Option"))
 	}
 	return
 }
 
+// an invalid base url is returned by every call until SetBaseURL
 func (srv *Service) setBaseUrl(group *Group) {
-	if len(srv.baseUrl.ids) == 0 {
-		group.Id(srv.self).Dot(FieldBaseUrl).Op("=").Lit(srv.baseUrl.pattern)
-	} else if srv.baseUrl.pattern == StringPlaceholder {
-		group.Id(srv.self).Dot(FieldBaseUrl).Op("=").Id(srv.baseUrl.ids[0])
-	} else {
-		// add Format pkg
-		group.Id(srv.self).Dot(FieldBaseUrl).Op("=").
-			Qual(FormatPkg, "Sprintf").Call(Lit(srv.baseUrl.pattern), List(genIds(srv.baseUrl.ids)...))
+	baseUrls := make([]Code, 0)
+	for _, baseUrl := range srv.baseUrls {
+		baseUrls = append(baseUrls, baseUrl.genValue())
 	}
+	group.Id(srv.self).Dot(FieldBaseUrl).Op("=").Id(srv.self).Dot(FieldConfig).Dot("NewBaseURL").Call(baseUrls...)
 }
 
 func (srv *Service) addHeader(group *Group) {
@@ -125,38 +176,14 @@ func (srv *Service) addHeader(group *Group) {
 }
 
 func (srv *Service) addCookies(group *Group) {
-	for _, pattern := range srv.cookieVars {
-		if len(pattern.ids) == 0 {
-			group.Id(srv.self).Dot(FieldCookies).Op("=").Append(
-				Id(srv.self).Dot(FieldCookies),
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Lit(pattern.pattern),
-				}),
-			)
-		} else if pattern.pattern == StringPlaceholder {
-			group.Id(srv.self).Dot(FieldCookies).Op("=").Append(
-				Id(srv.self).Dot(FieldCookies),
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Id(pattern.ids[0]),
-				}),
-			)
-		} else {
-			group.Id(srv.self).Dot(FieldCookies).Op("=").Append(
-				Id(srv.self).Dot(FieldCookies),
-				Op("&").Qual(HttpPkg, "Cookie").Values(Dict{
-					Id("Name"):  Lit(pattern.key),
-					Id("Value"): Qual(FormatPkg, "Sprintf").Call(Lit(pattern.pattern), List(genIds(pattern.ids)...)),
-				}),
-			)
-		}
+	for _, cookie := range srv.cookieVars {
+		group.Id(srv.self).Dot(FieldCookies).Op("=").Append(Id(srv.self).Dot(FieldCookies), cookie.literal())
 	}
 }
 
 func (srv *Service) InitComments(cmap ast.CommentMap) *Service {
 	for node := range cmap {
-		if tok, ok := node.(*ast.GenDecl); ok &&  !srv.Complete() {
+		if tok, ok := node.(*ast.GenDecl); ok && !srv.Complete() {
 			srv.TrySetNode(tok)
 		}
 	}
@@ -189,6 +216,8 @@ func (srv *Service) TrySetNode(node *ast.GenDecl) {
 						srv.setMethods(service)
 						srv.service = service
 						srv.commentText = combineComments(node.Doc.Text(), typ.Doc.Text())
+						srv.docs = []*ast.CommentGroup{node.Doc, typ.Doc}
+						srv.pos = typ.Name.Pos()
 					}
 				}
 			}
@@ -215,6 +244,7 @@ func (srv *Service) TryAddField(node *ast.Field) {
 	if method, ok := srv.methods[node.Pos()]; ok {
 		if len(node.Names) == 1 && method.Name() == node.Names[0].String() {
 			method.commentText = strings.Trim(node.Doc.Text(), LF)
+			method.docs = []*ast.CommentGroup{node.Doc}
 		}
 	}
 }
@@ -254,6 +284,9 @@ func (srv Service) String() string {
 }
 
 func (srv *Service) resolveMetadata() (err error) {
+	if srv.service == nil {
+		return ServiceNotFoundError(srv.name)
+	}
 	err = processor.NewProcessor(srv.commentText).Scan(func(ann, key, value string) (err error) {
 		switch ann {
 		case BaseAnn:
@@ -261,23 +294,103 @@ func (srv *Service) resolveMetadata() (err error) {
 		case HeaderAnn:
 			srv.ServiceMeta.addHeader(key, value)
 		case CookieAnn:
-			srv.ServiceMeta.addCookie(key, value)
+			err = srv.ServiceMeta.tryAddCookie(key, value)
+		case CompressAnn:
+			err = srv.ServiceMeta.trySetCompress(value)
+		case BodyAnn:
+			err = srv.ServiceMeta.trySetBodyType(value)
+		case ResultAnn:
+			err = srv.ServiceMeta.trySetResultType(value)
+		case TimeoutAnn:
+			err = srv.ServiceMeta.trySetTimeout(value)
+		case RetryAnn:
+			err = srv.ServiceMeta.trySetRetry(key, value)
+		case AcceptAnn:
+			err = srv.ServiceMeta.trySetAccept(value)
+		case CircuitBreakerAnn:
+			err = srv.ServiceMeta.trySetBreaker(key, value)
+		case RateLimitAnn:
+			err = srv.ServiceMeta.trySetRateLimit(value)
+		case CacheAnn:
+			err = srv.ServiceMeta.trySetCache(key, value)
+		case SingleflightAnn:
+			err = srv.ServiceMeta.trySetSingleflight()
+		case LeftParamsAnn:
+			err = srv.ServiceMeta.trySetLeftMode(value)
 		}
 		return
 	})
 	if err == nil {
 		srv.resolveBaseUrl()
+		err = srv.checkBaseUrl()
+	}
+	if err == nil {
+		err = srv.resolveReservedMethods()
 	}
 	return
 }
 
-func (meta *ServiceMeta) trySetBaseUrl(baseUrl string) (err error) {
-	if meta.baseUrl != nil {
+// @Base https://a.example.com/api, https://b.example.com/api
+func (meta *ServiceMeta) trySetBaseUrl(value string) (err error) {
+	if meta.baseUrls != nil {
 		err = DuplicatedAnnotationError(BaseAnn)
 	}
 	if err == nil {
-		Log.Debugf("Set BaseURL: %s", baseUrl)
-		meta.baseUrl = meta.genPatternMeta("baseUrl", baseUrl)
+		meta.baseUrls = make([]*PatternMeta, 0)
+		for _, baseUrl := range strings.Split(value, ",") {
+			Log.Debugf("Set BaseURL: %s", strings.TrimSpace(baseUrl))
+			meta.baseUrls = append(meta.baseUrls, meta.genPatternMeta("baseUrl", strings.TrimSpace(baseUrl)))
+		}
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetCompress(value string) (err error) {
+	if meta.compress != ZeroStr {
+		err = DuplicatedAnnotationError(CompressAnn)
+	} else if !compression.IsSupported(value) {
+		err = UnsupportedAnnotationValueError(CompressAnn, value)
+	}
+	if err == nil {
+		Log.Debugf("Set Compress: %s", value)
+		meta.compress = value
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetBodyType(value string) (err error) {
+	if meta.requestType != ZeroStr {
+		err = DuplicatedAnnotationError(BodyAnn)
+	} else if value != JSON && value != XML && value != Form && value != Multipart {
+		err = UnsupportedAnnotationValueError(BodyAnn, value)
+	}
+	if err == nil {
+		Log.Debugf("Set Default Request Body: %s", value)
+		meta.requestType = BodyType(value)
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetResultType(value string) (err error) {
+	if len(meta.resultTypes) != 0 {
+		err = DuplicatedAnnotationError(ResultAnn)
+	}
+	if err == nil {
+		meta.resultTypes, err = parseResultTypes(value)
+		if err == nil {
+			Log.Debugf("Set Default Result Type: %s", value)
+		}
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetAccept(value string) (err error) {
+	if meta.accept != ZeroStr {
+		err = DuplicatedAnnotationError(AcceptAnn)
+	}
+	if err == nil {
+		Log.Debugf("Set Default Accept: %s", value)
+		meta.accept = value
 	}
 	return
 }
@@ -289,19 +402,20 @@ func (meta *ServiceMeta) addHeader(key, value string) {
 	meta.headerVars = append(meta.headerVars, patternMeta)
 }
 
-func (meta *ServiceMeta) addCookie(key, value string) {
-	Log.Debugf("Add Cookie: %s(%s)", key, value)
-	var patternMeta *PatternMeta
-	patternMeta = meta.genPatternMeta(key, value)
-	meta.cookieVars = append(meta.cookieVars, patternMeta)
+func (meta *ServiceMeta) tryAddCookie(key, value string) (err error) {
+	name, attributes, err := parseCookieKey(key)
+	if err == nil {
+		Log.Debugf("Add Cookie: %s(%s)", key, value)
+		meta.cookieVars = append(meta.cookieVars, &CookieMeta{PatternMeta: meta.genPatternMeta(name, value), attributes: attributes})
+	}
 	return
 }
 
 func (meta *ServiceMeta) resolveBaseUrl() {
-	if meta.baseUrl == nil {
+	if meta.baseUrls == nil {
 		baseUrl := "{baseUrl}"
 		Log.Debugf("Set BaseURL: %s", baseUrl)
-		meta.baseUrl = meta.genPatternMeta("baseUrl", baseUrl)
+		meta.baseUrls = []*PatternMeta{meta.genPatternMeta("baseUrl", baseUrl)}
 	}
 }
 
@@ -313,13 +427,25 @@ func (meta *ServiceMeta) genPatternMeta(key, pattern string) (patternMeta *Patte
 	patterns := IdRe.FindAllString(pattern, -1)
 	for _, pattern := range patterns {
 		id := getIdFromPattern(pattern)
-		meta.idList = append(meta.idList, id)
+		if !meta.hasId(id) {
+			meta.idList = append(meta.idList, id)
+		}
 		patternMeta.ids = append(patternMeta.ids, id)
 	}
 	patternMeta.pattern = IdRe.ReplaceAllStringFunc(pattern, meta.findAndReplace)
 	return
 }
 
+// an id used by several patterns is a single param of constructor
+func (meta *ServiceMeta) hasId(id string) bool {
+	for _, item := range meta.idList {
+		if item == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (meta ServiceMeta) findAndReplace(pattern string) (placeholder string) {
 	placeholder = StringPlaceholder
 	return
@@ -0,0 +1,51 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"net/http"
+)
+
+func (meta *ServiceMeta) trySetSingleflight() (err error) {
+	if meta.singleflight {
+		err = DuplicatedAnnotationError(SingleflightAnn)
+	}
+	meta.singleflight = true
+	return
+}
+
+func (meta *MethodMeta) TrySetSingleflight() (err error) {
+	if meta.singleflight {
+		err = DuplicatedAnnotationError(SingleflightAnn)
+	}
+	meta.singleflight = true
+	return
+}
+
+// the service default applies to idempotent methods only; a method in singleflight must be idempotent
+func (method *Method) resolveSingleflight() (err error) {
+	if !method.singleflight && method.idempotent() {
+		method.singleflight = method.service.singleflight
+	} else if method.singleflight && !method.idempotent() {
+		err = ConflictAnnotationError(SingleflightAnn, method.signature)
+	}
+	return
+}
+
+// GET, HEAD or OPTIONS without body, whose response is read completely
+func (method *Method) idempotent() bool {
+	return (method.httpMethod == http.MethodGet || method.httpMethod == http.MethodHead || method.httpMethod == http.MethodOptions) &&
+		len(method.bodyVars) == 0 && method.resultType != HttpRequest && !method.isStream()
+}
+
+// genResponse, genErr = service.config.<accessor>().Do(genRequest, args..., func(genRequest *http.Request) (...) { inner })
+func (method *Method) wrapDo(group *Group, accessor string, args []Code, inner func(group *Group)) {
+	args = append(append([]Code{Id(IdRequest)}, args...), Func().
+		Params(Id(IdRequest).Op("*").Qual(HttpPkg, "Request")).
+		Params(Id(IdResponse).Op("*").Qual(HttpPkg, "Response"), Id(IdError).Error()).
+		BlockFunc(func(group *Group) {
+			inner(group)
+			group.Return()
+		}))
+	group.List(Id(IdResponse), Id(IdError)).Op("=").
+		Id(method.service.self).Dot(FieldConfig).Dot(accessor).Call().Dot("Do").Call(args...)
+}
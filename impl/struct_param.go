@@ -0,0 +1,353 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	. "github.com/rady-io/http-service/log"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+const (
+	// tags of fields in a struct param. etc. `path:"id"` | `query:"page,omitempty"`
+	PathTag   = "path"
+	QueryTag  = "query"
+	HeaderTag = "header"
+	CookieTag = "cookie"
+	FormTag   = "form"
+	JSONTag   = "json"
+	XMLTag    = "xml"
+
+	OmitEmpty = "omitempty"
+	IgnoreTag = "-"
+)
+
+var (
+	// a struct param is expanded only when any field has one of these tags
+	locationTags = []string{PathTag, QueryTag, HeaderTag, CookieTag, FormTag}
+	bodyTags     = []string{JSONTag, XMLTag}
+)
+
+type (
+	// field of a struct param
+	FieldMeta struct {
+		name   string
+		tags   reflect.StructTag
+		expr   string     // selector from the param. etc. req.Paging.Page
+		typ    types.Type // type of field
+		guards []string   // nested pointers to check before access. etc. req.Paging
+	}
+)
+
+// expand struct params whose fields are tagged with a location;
+// path fields are registered as ids so that patterns can refer to them
+func (method *Method) resolveStructParams() (err error) {
+	params := method.signature.Params()
+	for i := 0; i < params.Len() && err == nil; i++ {
+		param := params.At(i)
+		structType, isPointer := getStructType(param.Type())
+		if structType == nil || !hasLocationTag(structType, make(map[*types.Struct]bool)) {
+			continue
+		}
+		Log.Debugf("Expand Struct Param: %s", param.Name())
+		fields := collectFields(structType, param.Name(), make([]string, 0), make(map[*types.Struct]bool))
+		for _, field := range fields {
+			if err = field.checkEncoding(); err != nil {
+				break
+			}
+			if key, _, ok := field.lookup(PathTag); ok {
+				if len(field.guards) != 0 {
+					err = PathFieldUnderPointerError(field.expr)
+					break
+				}
+				if _, exist := method.totalIds[key]; exist {
+					err = DuplicatedPathIdError(key)
+					break
+				}
+				method.totalIds[key] = &ParamMeta{key: field.expr, typ: getParamType(field.typ), goType: field.typ}
+			}
+		}
+		if err == nil {
+			if isPointer {
+				method.nilStructs = append(method.nilStructs, param)
+			}
+			method.structVars[param.Name()] = fields
+		}
+	}
+	return
+}
+
+func getStructType(typ types.Type) (structType *types.Struct, isPointer bool) {
+	if pointer, ok := typ.(*types.Pointer); ok {
+		typ = pointer.Elem()
+		isPointer = true
+	}
+	structType, _ = typ.Underlying().(*types.Struct)
+	return
+}
+
+// visited breaks recursive types
+func hasLocationTag(structType *types.Struct, visited map[*types.Struct]bool) (has bool) {
+	visited[structType] = true
+	for i := 0; i < structType.NumFields() && !has; i++ {
+		tags := reflect.StructTag(structType.Tag(i))
+		for _, tag := range locationTags {
+			if _, ok := tags.Lookup(tag); ok {
+				has = true
+			}
+		}
+		if nested, _ := getStructType(structType.Field(i).Type()); !has && nested != nil && !visited[nested] &&
+			(structType.Field(i).Exported() || structType.Field(i).Embedded()) && untagged(tags) {
+			has = hasLocationTag(nested, visited)
+		}
+	}
+	return
+}
+
+// untagged nested structs are expanded if they have any location tag, and embedded structs are always expanded
+func isExpandable(field *types.Var, tags reflect.StructTag, visited map[*types.Struct]bool) bool {
+	nested, _ := getStructType(field.Type())
+	if nested == nil || visited[nested] || !field.Exported() && !field.Embedded() || !untagged(tags) {
+		return false
+	}
+	return field.Embedded() || hasLocationTag(nested, copyVisited(visited))
+}
+
+func copyVisited(visited map[*types.Struct]bool) map[*types.Struct]bool {
+	copied := make(map[*types.Struct]bool)
+	for structType := range visited {
+		copied[structType] = true
+	}
+	return copied
+}
+
+func untagged(tags reflect.StructTag) bool {
+	for _, tag := range append(locationTags, bodyTags...) {
+		if _, ok := tags.Lookup(tag); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func collectFields(structType *types.Struct, expr string, guards []string, visited map[*types.Struct]bool) (fields []*FieldMeta) {
+	visited[structType] = true
+	fields = make([]*FieldMeta, 0)
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tags := reflect.StructTag(structType.Tag(i))
+		fieldExpr := expr + "." + field.Name()
+		if isExpandable(field, tags, visited) {
+			nested, isPointer := getStructType(field.Type())
+			nestedGuards := guards
+			if isPointer {
+				nestedGuards = append(append(make([]string, 0), guards...), fieldExpr)
+			}
+			fields = append(fields, collectFields(nested, fieldExpr, nestedGuards, copyVisited(visited))...)
+		} else if field.Exported() {
+			fields = append(fields, &FieldMeta{
+				name:   field.Name(),
+				tags:   tags,
+				expr:   fieldExpr,
+				typ:    field.Type(),
+				guards: guards,
+			})
+		}
+	}
+	return
+}
+
+// fields in query, header and cookie must be form-encodable
+func (field *FieldMeta) checkEncoding() (err error) {
+	for _, tag := range []string{QueryTag, HeaderTag, CookieTag} {
+		if _, _, ok := field.lookup(tag); ok && err == nil {
+			_, err = encodeFormValue(Lit(tag), Id(field.expr), field.typ, func(key Code, value Code) *Statement {
+				return Null()
+			})
+		}
+	}
+	return
+}
+
+func (field *FieldMeta) lookup(tag string) (key string, omitEmpty bool, ok bool) {
+	var value string
+	if value, ok = field.tags.Lookup(tag); ok {
+		options := strings.Split(value, ",")
+		key = options[0]
+		if key == IgnoreTag {
+			ok = false
+		}
+		if key == ZeroStr {
+			key = field.name
+		}
+		for _, option := range options[1:] {
+			if option == OmitEmpty {
+				omitEmpty = true
+			}
+		}
+	}
+	return
+}
+
+// guards and the omitempty check; nil if unconditional
+func (field *FieldMeta) condition(omitEmpty bool) (condition *Statement) {
+	conditions := make([]Code, 0)
+	for _, guard := range field.guards {
+		conditions = append(conditions, Id(guard).Op("!=").Nil())
+	}
+	if omitEmpty {
+		conditions = append(conditions, notEmpty(Id(field.expr), field.typ))
+	}
+	for _, cond := range conditions {
+		if condition == nil {
+			condition = Add(cond)
+		} else {
+			condition = condition.Op("&&").Add(cond)
+		}
+	}
+	return
+}
+
+func notEmpty(value *Statement, typ types.Type) Code {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case underlying.Info()&types.IsString != 0:
+			return value.Op("!=").Lit(ZeroStr)
+		case underlying.Info()&types.IsBoolean != 0:
+			return value
+		case underlying.Info()&types.IsNumeric != 0:
+			return value.Op("!=").Lit(0)
+		}
+	case *types.Slice, *types.Map, *types.Chan:
+		return Len(value).Op("!=").Lit(0)
+	case *types.Pointer, *types.Interface, *types.Signature:
+		return value.Op("!=").Nil()
+	}
+	return Op("!").Qual(ReflectPkg, "ValueOf").Call(value).Dot("IsZero").Call()
+}
+
+// string value of a field for query, header and cookie
+func formatValue(value *Statement, typ types.Type) Code {
+	if basic, ok := typ.(*types.Basic); ok && basic.Kind() == types.String {
+		return value
+	}
+	if basic, ok := typ.Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+		return String().Call(value)
+	}
+	return Qual(FormatPkg, "Sprint").Call(value)
+}
+
+// add each field tagged with `tag` by the form encoder
+func (method *Method) addFieldVars(group *Group, tag string, add FormAdder) {
+	for _, fields := range method.sortedStructVars() {
+		for _, field := range fields {
+			key, omitEmpty, ok := field.lookup(tag)
+			if !ok {
+				continue
+			}
+			statements, _ := encodeFormValue(Lit(key), Id(field.expr), field.typ, add)
+			if condition := field.condition(omitEmpty); condition != nil {
+				group.If(condition).Block(statements...)
+			} else {
+				addStatements(group, statements)
+			}
+		}
+	}
+}
+
+func (method *Method) addQueryFields(group *Group) {
+	if !method.hasFieldVars(QueryTag) && len(method.queryVars) == 0 {
+		return
+	}
+	add := func(key Code, value Code) *Statement {
+		return Id(IdQuery).Dot("Add").Call(key, value)
+	}
+	group.Id(IdQuery).Op(":=").Id(IdRequest).Dot("URL").Dot("Query").Call()
+	method.addFieldVars(group, QueryTag, add)
+	method.addQueryVars(group, add)
+	group.Id(IdRequest).Dot("URL").Dot("RawQuery").Op("=").Id(IdQuery).Dot("Encode").Call()
+}
+
+func (method *Method) hasFieldVars(tag string) bool {
+	for _, fields := range method.structVars {
+		for _, field := range fields {
+			if _, _, ok := field.lookup(tag); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// param order, for a stable output
+func (method *Method) sortedStructVars() [][]*FieldMeta {
+	sorted := make([][]*FieldMeta, 0)
+	params := method.signature.Params()
+	for i := 0; i < params.Len(); i++ {
+		if fields, ok := method.structVars[params.At(i).Name()]; ok {
+			sorted = append(sorted, fields)
+		}
+	}
+	return sorted
+}
+
+// a nil struct param is replaced by its zero value
+func (method *Method) genNilStructs(group *Group) {
+	for _, param := range method.nilStructs {
+		group.If(Id(param.Name()).Op("==").Nil()).Block(
+			Id(param.Name()).Op("=").New(getTypeQual(param.Type().(*types.Pointer).Elem())),
+		)
+	}
+}
+
+// fields tagged for the body of request type, or untagged fields
+func (meta *MethodMeta) resolveStructBodyVars(fields []*FieldMeta) {
+	tag := JSONTag
+	switch meta.requestType {
+	case XML:
+		tag = XMLTag
+	case Form, Multipart:
+		tag = FormTag
+	}
+	bodyVars := structBodyVars(fields, tag)
+	if tag != FormTag {
+		// json and xml encode a field as its type, 5 rather than "5"
+		for _, bodyVar := range bodyVars {
+			if bodyVar.typ == TypeInt {
+				bodyVar.typ, bodyVar.pattern = Other, ZeroStr
+			}
+		}
+	}
+	meta.bodyVars = append(meta.bodyVars, bodyVars...)
+}
+
+// fields tagged with tag, or untagged fields
+func structBodyVars(fields []*FieldMeta, tag string) (bodyVars []*BodyMeta) {
+	bodyVars = make([]*BodyMeta, 0)
+	for _, field := range fields {
+		key, omitEmpty, ok := field.lookup(tag)
+		if !ok && untagged(field.tags) {
+			key, ok = field.name, true
+		}
+		if !ok {
+			continue
+		}
+		Log.Debugf("Set Param(%s) <- %s", key, field.expr)
+		paramType := getParamType(field.typ)
+		patternMeta := &PatternMeta{key: key, ids: []string{field.expr}}
+		switch paramType {
+		case TypeString:
+			patternMeta.pattern = StringPlaceholder
+		case TypeInt:
+			patternMeta.pattern = IntPlaceholder
+		}
+		bodyVars = append(bodyVars, &BodyMeta{
+			PatternMeta: patternMeta,
+			typ:         paramType,
+			goType:      field.typ,
+			condition:   field.condition(omitEmpty),
+		})
+	}
+	return
+}
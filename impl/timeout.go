@@ -0,0 +1,81 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"strings"
+	"time"
+)
+
+const (
+	IdTimeoutCtx = "genTimeoutCtx"
+	IdCancel     = "genCancel"
+)
+
+// @Timeout 5s
+func parseTimeout(value string) (timeout time.Duration, err error) {
+	timeout, err = time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || timeout <= 0 {
+		timeout, err = 0, UnsupportedAnnotationValueError(TimeoutAnn, value)
+	}
+	return
+}
+
+func (meta *ServiceMeta) trySetTimeout(value string) (err error) {
+	if meta.timeout != 0 {
+		err = DuplicatedAnnotationError(TimeoutAnn)
+	}
+	if err == nil {
+		meta.timeout, err = parseTimeout(value)
+	}
+	return
+}
+
+func (meta *MethodMeta) TrySetTimeout(value string) (err error) {
+	if meta.timeout != 0 {
+		err = DuplicatedAnnotationError(TimeoutAnn)
+	}
+	if err == nil {
+		meta.timeout, err = parseTimeout(value)
+	}
+	return
+}
+
+// the service timeout applies to methods reading the response completely; so must a method timeout,
+// as the response body of other methods is read after they return
+func (method *Method) resolveTimeout() (err error) {
+	if method.timeout == 0 && method.readsResponse() {
+		method.timeout = method.service.timeout
+	} else if method.timeout != 0 && !method.readsResponse() {
+		err = ConflictAnnotationError(TimeoutAnn, method.signature)
+	}
+	return
+}
+
+// the result is decoded from the whole response before the method returns
+func (method *Method) readsResponse() bool {
+	return method.resultType != HttpRequest && method.resultType != HttpResponse && !method.isStream()
+}
+
+// the call, its retries and reading the response are canceled after the timeout
+func (method *Method) withTimeout(group *Group) {
+	if method.timeout != 0 {
+		group.List(Id(IdTimeoutCtx), Id(IdCancel)).Op(":=").
+			Qual(ContextPkg, "WithTimeout").Call(Id(IdRequest).Dot("Context").Call(), durationCode(method.timeout))
+		group.Defer().Id(IdCancel).Call()
+		group.Id(IdRequest).Op("=").Id(IdRequest).Dot("WithContext").Call(Id(IdTimeoutCtx))
+	}
+}
+
+// 30s -> 30 * time.Second
+func durationCode(duration time.Duration) Code {
+	units := []struct {
+		name string
+		unit time.Duration
+	}{{"Hour", time.Hour}, {"Minute", time.Minute}, {"Second", time.Second}, {"Millisecond", time.Millisecond}, {"Microsecond", time.Microsecond}}
+	for _, item := range units {
+		if duration%item.unit == 0 {
+			return Lit(int(duration/item.unit)).Op("*").Qual(TimePkg, item.name)
+		}
+	}
+	return Qual(TimePkg, "Duration").Call(Lit(int64(duration)))
+}
@@ -0,0 +1,50 @@
+package impl
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"net/http"
+	"strings"
+)
+
+const (
+	IdSpan = "genSpan"
+	IdCall = "genCall"
+)
+
+// path template of method without query. etc. /item/{id}
+func (meta *MethodMeta) setRoute(uriPattern string) {
+	meta.route = stripFormats(strings.SplitN(uriPattern, "?", 2)[0])
+	if meta.route == ZeroStr {
+		meta.route = "/"
+	}
+}
+
+// genCall := &client.Call{...}, shared by the tracer and metrics
+func (method *Method) genCall(group *Group) {
+	group.Id(IdCall).Op(":=").Add(method.callInfo())
+}
+
+// &client.Call{...} describing method
+func (method *Method) callInfo() Code {
+	httpMethod := method.httpMethod
+	if httpMethod == ZeroStr {
+		httpMethod = http.MethodGet
+	}
+	return Op("&").Qual(ClientPkg, "Call").Values(Dict{
+		Id("Service"):    Lit(method.service.name),
+		Id("Method"):     Lit(method.Name()),
+		Id("HTTPMethod"): Lit(httpMethod),
+		Id("Route"):      Lit(method.route),
+	})
+}
+
+// the span covers the call only; requests rejected by the limiter or the breaker are not traced
+func (method *Method) startSpan(group *Group) {
+	group.Var().Id(IdSpan).Qual(ClientPkg, "Span")
+	group.List(Id(IdRequest), Id(IdSpan)).Op("=").
+		Id(method.service.self).Dot(FieldConfig).Dot("Tracer").Call().Dot("Start").Call(Id(IdRequest), Id(IdCall))
+}
+
+func (method *Method) endSpan(group *Group) {
+	group.Id(IdSpan).Dot("End").Call(Id(IdResponse), Id(IdError))
+}
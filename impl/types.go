@@ -14,26 +14,53 @@ const (
 package types
 
 import (
+	"context"
+	"encoding"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"time"
 )
 
 var (
 	IOReader 	io.Reader
 	Err      	error
+	ErrChan		<-chan error
 	StatusCode	int
 	Request		*http.Request
 	Response	*http.Response
+	Context		context.Context
+	Bytes		[]byte
+	OSFile		*os.File
+	FS			fs.FS
+	Header		http.Header
+	Cookies		[]*http.Cookie
+	StringMap	map[string]string
+	TextMarshaler	encoding.TextMarshaler
+	Time		time.Time
+	Duration	time.Duration
 )
 `
 )
 
 const (
-	TypeIOReader   = "IOReader"
-	TypeErr        = "Err"
-	TypeStatusCode = "StatusCode"
-	TypeRequest    = "Request"
-	TypeResponse   = "Response"
+	TypeIOReader      = "IOReader"
+	TypeErr           = "Err"
+	TypeErrChan       = "ErrChan"
+	TypeStatusCode    = "StatusCode"
+	TypeRequest       = "Request"
+	TypeResponse      = "Response"
+	TypeContext       = "Context"
+	TypeBytes         = "Bytes"
+	TypeOSFile        = "OSFile"
+	TypeFS            = "FS"
+	TypeHeader        = "Header"
+	TypeCookies       = "Cookies"
+	TypeStringMap     = "StringMap"
+	TypeTextMarshaler = "TextMarshaler"
+	TypeTime          = "Time"
+	TypeDuration      = "Duration"
 )
 
 func GetType(name string) types.Type {
@@ -17,3 +17,17 @@ var (
 func init() {
 	logging.SetBackend(backendFormatter)
 }
+
+// SetVerbose logs debug messages if verbose, otherwise info and above
+func SetVerbose(verbose bool) {
+	level := logging.INFO
+	if verbose {
+		level = logging.DEBUG
+	}
+	logging.SetLevel(level, "")
+}
+
+// SetQuiet logs warnings and errors only
+func SetQuiet() {
+	logging.SetLevel(logging.WARNING, "")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/rady-io/http-service/impl"
 	. "github.com/rady-io/http-service/log"
@@ -9,9 +10,8 @@ import (
 	"go/parser"
 	"go/token"
 	"go/types"
-	"io/ioutil"
 	"os"
-	"strings"
+	"path/filepath"
 )
 
 const (
@@ -20,41 +20,145 @@ const (
 	ZeroStr   = ""
 )
 
+const (
+	// subcommands; gen if omitted
+	CmdGen     = "gen"
+	CmdCheck   = "check"
+	CmdOpenAPI = "openapi"
+	CmdMock    = "mock"
+	CmdLint    = "lint"
+)
+
+const usage = `usage: impler <command> [flags] [Service]
+
+commands:
+  gen      generate the implement of a service (default)
+  check    resolve annotations of a service and report the first error
+  openapi  describe routes of a service as an OpenAPI document
+  mock     generate a mock of a service with func fields
+  lint     report suspicious annotations of a service in the go vet format
+
+Service may be given by -type; -file and -pkg default to $GOFILE and $GOPACKAGE under go generate.
+Run 'impler <command> -h' for flags of a command.
+`
+
+type (
+	// flags shared by all commands
+	Options struct {
+		*flag.FlagSet
+		typ, file, pkg string
+		verbose        bool
+	}
+
+	// a type-checked file with the service
+	Source struct {
+		fset    *token.FileSet
+		file    *ast.File
+		info    *types.Info
+		cmap    ast.CommentMap
+		path    string // file path
+		dir     string // directory of the file
+		pkgName string
+		pkgPath string // import path, or pkgName if generated in place
+	}
+
+	Command struct {
+		name  string
+		setup func(options *Options) func(source *Source) error
+	}
+)
+
 var (
-	GoFile = os.Getenv(GoFileKey)
-	GoPkg  = os.Getenv(GoPkgKey)
+	commands = []*Command{
+		{CmdGen, setupGen},
+		{CmdCheck, setupCheck},
+		{CmdOpenAPI, setupOpenAPI},
+		{CmdMock, setupMock},
+		{CmdLint, setupLint},
+	}
 )
 
 func main() {
-	if GoFile == ZeroStr || GoPkg == ZeroStr {
-		Log.Fatal("$GOFILE and $GOPACKAGE cannot be empty")
+	args := os.Args[1:]
+	command := commands[0]
+	if len(args) != 0 {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			fmt.Fprint(os.Stderr, usage)
+			return
+		}
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				command, args = cmd, args[1:]
+				break
+			}
+		}
 	}
-	serviceName := os.Args[1]
-	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, GoFile, nil, parser.ParseComments)
-	if err != nil {
+	if err := command.run(args); err != nil {
 		Log.Fatal(err.Error())
 	}
-	cmap := ast.NewCommentMap(fset, file, file.Comments)
-	conf := types.Config{Importer: importer.Default()}
+}
 
-	info := &types.Info{
-		Types: make(map[ast.Expr]types.TypeAndValue),
-		Defs:  make(map[*ast.Ident]types.Object),
-		Uses:  make(map[*ast.Ident]types.Object),
-	}
+func (command *Command) run(args []string) (err error) {
+	options := &Options{FlagSet: flag.NewFlagSet("impler "+command.name, flag.ExitOnError)}
+	options.StringVar(&options.typ, "type", ZeroStr, "name of the service interface")
+	options.StringVar(&options.file, "file", os.Getenv(GoFileKey), "file declaring the service; $GOFILE by default")
+	options.StringVar(&options.pkg, "pkg", os.Getenv(GoPkgKey), "package name of the file; $GOPACKAGE or the package clause by default")
+	options.BoolVar(&options.verbose, "v", false, "log debug messages")
+	action := command.setup(options)
+	options.Parse(args)
+	SetVerbose(options.verbose)
 
-	_, err = conf.Check(GoPkg, fset, []*ast.File{file}, info)
-	if err != nil {
-		Log.Fatal(err.Error()) // type error
+	if options.typ == ZeroStr && options.NArg() == 1 {
+		options.typ = options.Arg(0)
+	}
+	switch {
+	case options.typ == ZeroStr || options.NArg() > 1 || options.NArg() == 1 && options.Arg(0) != options.typ:
+		options.Usage()
+		err = fmt.Errorf("one service is required by -type or an argument")
+	case options.file == ZeroStr:
+		err = fmt.Errorf("-file or $%s is required", GoFileKey)
 	}
+	var source *Source
+	if err == nil {
+		source, err = load(options.file, options.pkg)
+	}
+	if err == nil {
+		err = action(source)
+	}
+	return
+}
 
-	service := impl.NewService(serviceName, info).InitComments(cmap)
-	code, err := impl.Impl(service, GoPkg)
-	if err != nil {
-		Log.Fatal(err.Error())
+// parse the file; it is type-checked by commands when the import path is known
+func load(path, pkgName string) (source *Source, err error) {
+	source = &Source{
+		fset:    token.NewFileSet(),
+		path:    path,
+		dir:     filepath.Dir(path),
+		pkgName: pkgName,
+		info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		},
+	}
+	if source.file, err = parser.ParseFile(source.fset, path, nil, parser.ParseComments); err != nil {
+		return
 	}
+	if source.pkgName == ZeroStr {
+		source.pkgName = source.file.Name.Name
+	}
+	source.pkgPath = source.pkgName
+	source.cmap = ast.NewCommentMap(source.fset, source.file, source.file.Comments)
+	return
+}
+
+// type-check the file as package pkgPath
+func (source *Source) check() (err error) {
+	conf := types.Config{Importer: importer.Default()}
+	_, err = conf.Check(source.pkgPath, source.fset, []*ast.File{source.file}, source.info)
+	return // type error
+}
 
-	implFileName := fmt.Sprintf("%s_impl.go", strings.ToLower(serviceName))
-	ioutil.WriteFile(implFileName, []byte(code), 0644)
+func (source *Source) service(name string) *impl.Service {
+	return impl.NewService(name, source.info).InitComments(source.cmap)
 }
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/rady-io/http-service/client"
+	"strconv"
+)
+
+const (
+	// labels of every metric, in order
+	LabelService = "service"
+	LabelMethod  = "method"
+	LabelRoute   = "route"
+	LabelCode    = "code" // status code, or "error"
+
+	DurationName = "http_client_request_duration_seconds"
+	RequestsName = "http_client_requests_total"
+	BytesOutName = "http_client_request_bytes_total"
+	BytesInName  = "http_client_response_bytes_total"
+)
+
+var (
+	Labels = []string{LabelService, LabelMethod, LabelRoute, LabelCode}
+
+	// buckets of DurationName, in seconds
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+type (
+	// ObserveFunc adds value to a labeled collector. etc. for a *prometheus.HistogramVec:
+	// func(value float64, labels ...string) { vec.WithLabelValues(labels...).Observe(value) }
+	ObserveFunc func(value float64, labels ...string)
+
+	// Collector is the client.Metrics adapter for Prometheus-style collectors; nil funcs are skipped
+	Collector struct {
+		Duration ObserveFunc // histogram
+		Requests ObserveFunc // counter
+		BytesOut ObserveFunc // counter
+		BytesIn  ObserveFunc // counter
+	}
+)
+
+// NewCollector registers the standard metrics in registry
+func NewCollector(registry *Registry) *Collector {
+	return &Collector{
+		Duration: registry.Histogram(DurationName, "Duration of outbound calls.", DefaultBuckets, Labels...),
+		Requests: registry.Counter(RequestsName, "Count of outbound calls.", Labels...),
+		BytesOut: registry.Counter(BytesOutName, "Bytes of request bodies.", Labels...),
+		BytesIn:  registry.Counter(BytesInName, "Bytes of response bodies.", Labels...),
+	}
+}
+
+func (collector *Collector) Observe(call *client.Call, observation *client.Observation) {
+	code := "error"
+	if observation.Err == nil {
+		code = strconv.Itoa(observation.StatusCode)
+	}
+	labels := []string{call.Service, call.Method, call.Route, code}
+	observe := func(fn ObserveFunc, value float64) {
+		if fn != nil {
+			fn(value, labels...)
+		}
+	}
+	observe(collector.Duration, observation.Duration.Seconds())
+	observe(collector.Requests, 1)
+	observe(collector.BytesOut, float64(observation.BytesOut))
+	observe(collector.BytesIn, float64(observation.BytesIn))
+}
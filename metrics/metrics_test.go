@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/rady-io/http-service/client"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollector_Observe(t *testing.T) {
+	registry := NewRegistry()
+	collector := NewCollector(registry)
+	call := &client.Call{Service: "Service", Method: "GetItem", HTTPMethod: "GET", Route: "/item/{id}"}
+	collector.Observe(call, &client.Observation{Duration: 20 * time.Millisecond, StatusCode: 200, BytesIn: 42})
+	collector.Observe(call, &client.Observation{Duration: time.Second, Err: errors.New("timeout")})
+
+	assert.Equal(t, float64(1), registry.Value(RequestsName, "Service", "GetItem", "/item/{id}", "200"))
+	assert.Equal(t, float64(42), registry.Value(BytesInName, "Service", "GetItem", "/item/{id}", "200"))
+	assert.Equal(t, float64(1), registry.Value(DurationName, "Service", "GetItem", "/item/{id}", "error"))
+
+	text := new(strings.Builder)
+	_, err := registry.WriteTo(text)
+	assert.Nil(t, err)
+	assert.Contains(t, text.String(), `http_client_request_duration_seconds_bucket{service="Service",method="GetItem",route="/item/{id}",code="200",le="0.025"} 1`)
+}
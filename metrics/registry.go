@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// Registry is a local registry of counters and histograms in the Prometheus text format,
+	// for tests and for services without a Prometheus client
+	Registry struct {
+		mutex    sync.Mutex
+		families []*family
+	}
+
+	family struct {
+		name, help, typ string
+		labels          []string
+		buckets         []float64 // only for histograms
+		series          map[string]*series
+	}
+
+	series struct {
+		labelValues []string
+		count       uint64
+		sum         float64
+		buckets     []uint64 // cumulative counts, only for histograms
+	}
+)
+
+func NewRegistry() *Registry {
+	return new(Registry)
+}
+
+func (registry *Registry) Counter(name, help string, labels ...string) ObserveFunc {
+	return registry.register(&family{name: name, help: help, typ: "counter", labels: labels, series: make(map[string]*series)})
+}
+
+func (registry *Registry) Histogram(name, help string, buckets []float64, labels ...string) ObserveFunc {
+	sorted := append(make([]float64, 0, len(buckets)), buckets...)
+	sort.Float64s(sorted)
+	return registry.register(&family{name: name, help: help, typ: "histogram", labels: labels, buckets: sorted, series: make(map[string]*series)})
+}
+
+func (registry *Registry) register(metric *family) ObserveFunc {
+	registry.mutex.Lock()
+	registry.families = append(registry.families, metric)
+	registry.mutex.Unlock()
+	return func(value float64, labelValues ...string) {
+		registry.mutex.Lock()
+		defer registry.mutex.Unlock()
+		key := strings.Join(labelValues, "\xff")
+		item, ok := metric.series[key]
+		if !ok {
+			item = &series{labelValues: labelValues, buckets: make([]uint64, len(metric.buckets))}
+			metric.series[key] = item
+		}
+		item.count++
+		item.sum += value
+		for i, bound := range metric.buckets {
+			if value <= bound {
+				item.buckets[i]++
+			}
+		}
+	}
+}
+
+// Value of a counter, or the count of a histogram; 0 if not observed
+func (registry *Registry) Value(name string, labelValues ...string) float64 {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for _, metric := range registry.families {
+		if item, ok := metric.series[strings.Join(labelValues, "\xff")]; ok && metric.name == name {
+			if metric.typ == "histogram" {
+				return float64(item.count)
+			}
+			return item.sum
+		}
+	}
+	return 0
+}
+
+// WriteTo writes all metrics in the Prometheus text format
+func (registry *Registry) WriteTo(writer io.Writer) (n int64, err error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	text := new(strings.Builder)
+	for _, metric := range registry.families {
+		fmt.Fprintf(text, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.typ)
+		keys := make([]string, 0, len(metric.series))
+		for key := range metric.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := metric.series[key]
+			labels := metric.formatLabels(item.labelValues)
+			if metric.typ == "counter" {
+				fmt.Fprintf(text, "%s%s %s\n", metric.name, wrap(labels), formatFloat(item.sum))
+				continue
+			}
+			for i, bound := range metric.buckets {
+				fmt.Fprintf(text, "%s_bucket%s %d\n", metric.name, wrap(append(labels, `le="`+formatFloat(bound)+`"`)), item.buckets[i])
+			}
+			fmt.Fprintf(text, "%s_bucket%s %d\n", metric.name, wrap(append(labels, `le="+Inf"`)), item.count)
+			fmt.Fprintf(text, "%s_sum%s %s\n", metric.name, wrap(labels), formatFloat(item.sum))
+			fmt.Fprintf(text, "%s_count%s %d\n", metric.name, wrap(labels), item.count)
+		}
+	}
+	written, err := io.WriteString(writer, text.String())
+	return int64(written), err
+}
+
+// Handler serves the metrics for scraping
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.WriteTo(writer)
+	})
+}
+
+func (metric *family) formatLabels(labelValues []string) []string {
+	labels := make([]string, 0, len(metric.labels))
+	for i, label := range metric.labels {
+		if i < len(labelValues) {
+			labels = append(labels, label+"="+strconv.Quote(labelValues[i]))
+		}
+	}
+	return labels
+}
+
+func wrap(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
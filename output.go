@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/rady-io/http-service/log"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const (
+	DefaultNameTemplate = "{{lower .Service}}_impl.go"
+	MockNameTemplate    = "{{lower .Service}}_mock.go"
+)
+
+var (
+	templateFuncs = template.FuncMap{"lower": strings.ToLower, "upper": strings.ToUpper}
+)
+
+type (
+	// flags of generated files
+	Output struct {
+		file, dir, pkg, name string
+	}
+
+	// data of the name template
+	NameData struct {
+		Service, Package, File string
+	}
+)
+
+func addOutputFlags(options *Options, nameTemplate string) *Output {
+	output := new(Output)
+	options.StringVar(&output.file, "o", ZeroStr, "output file; overrides -dir and -name")
+	options.StringVar(&output.dir, "dir", ZeroStr, "output directory, the directory of -file by default; another directory makes an external package importing the service")
+	options.StringVar(&output.pkg, "outpkg", ZeroStr, "output package name; base of -dir by default")
+	options.StringVar(&output.name, "name", nameTemplate, "template of the output file name, with .Service, .Package and .File")
+	return output
+}
+
+// type-check source in the package of the service, and resolve the output file and package
+func (output *Output) resolve(source *Source, serviceName string) (fileName, implPkgPath, implPkgName string, err error) {
+	if fileName, err = output.fileName(source, serviceName); err == nil {
+		implPkgPath, implPkgName, err = output.resolvePackage(source, filepath.Dir(fileName))
+	}
+	if err == nil {
+		err = source.check()
+	}
+	return
+}
+
+// -o, or -name in -dir
+func (output *Output) fileName(source *Source, serviceName string) (fileName string, err error) {
+	if output.file != ZeroStr {
+		return output.file, nil
+	}
+	dir := output.dir
+	if dir == ZeroStr {
+		dir = source.dir
+	}
+	var tmpl *template.Template
+	if tmpl, err = template.New("name").Funcs(templateFuncs).Parse(output.name); err == nil {
+		name := new(bytes.Buffer)
+		data := NameData{
+			Service: serviceName,
+			Package: source.pkgName,
+			File:    strings.TrimSuffix(filepath.Base(source.path), ".go"),
+		}
+		if err = tmpl.Execute(name, data); err == nil {
+			fileName = filepath.Join(dir, name.String())
+		}
+	}
+	return
+}
+
+// the service package is its name when generating into it,
+// otherwise both packages are resolved to import paths by go list
+func (output *Output) resolvePackage(source *Source, dir string) (implPkgPath, implPkgName string, err error) {
+	var srcDir, absDir string
+	if srcDir, err = filepath.Abs(source.dir); err == nil {
+		absDir, err = filepath.Abs(dir)
+	}
+	if err != nil {
+		return
+	}
+	implPkgPath, implPkgName = source.pkgName, output.pkg
+	if absDir != srcDir {
+		list := exec.Command("go", "list", "-f", "{{.ImportPath}}\n{{with .Module}}{{.Dir}}{{end}}", ".")
+		list.Dir = srcDir
+		var listed []byte
+		if listed, err = list.Output(); err == nil {
+			lines := strings.Split(string(listed), "\n")
+			source.pkgPath = lines[0]
+			implPkgPath, err = importPath(source.pkgPath, srcDir, lines[1], absDir)
+		}
+		if err == nil && implPkgName == ZeroStr {
+			implPkgName = packageName(path.Base(implPkgPath))
+		}
+	}
+	if err == nil && implPkgName != ZeroStr && !token.IsIdentifier(implPkgName) {
+		err = fmt.Errorf("invalid package name %q of %s, set one by -outpkg", implPkgName, dir)
+	}
+	return
+}
+
+// import path of dir; it must be in the module (or GOPATH root) of the service
+func importPath(pkgPath, srcDir, root, dir string) (importPath string, err error) {
+	if root == ZeroStr {
+		root = strings.TrimSuffix(srcDir, filepath.FromSlash(pkgPath))
+	}
+	var rel string
+	if rel, err = filepath.Rel(root, dir); err == nil && (rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+		err = fmt.Errorf("output directory %s is outside of %s", dir, root)
+	}
+	if err == nil {
+		rel, err = filepath.Rel(srcDir, dir)
+	}
+	if err == nil {
+		importPath = path.Join(pkgPath, filepath.ToSlash(rel))
+	}
+	return
+}
+
+// my-client -> myclient, as go tools name packages of such directories
+func packageName(base string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, base)
+}
+
+func write(fileName, code string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err == nil {
+		err = ioutil.WriteFile(fileName, []byte(code), 0644)
+	}
+	if err == nil {
+		Log.Infof("Write %s", fileName)
+	}
+	return
+}
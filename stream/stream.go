@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the last event of some LLM-style streams; decoded as io.EOF
+	Done = "[DONE]"
+)
+
+const (
+	fieldId    = "id"
+	fieldEvent = "event"
+	fieldData  = "data"
+	fieldRetry = "retry"
+	ZeroStr    = ""
+	LF         = "\n"
+)
+
+type (
+	// Decode returns io.EOF when the stream ends
+	Decoder interface {
+		Decode(v interface{}) error
+	}
+
+	Event struct {
+		Id    string
+		Event string
+		Data  string
+		Retry int // milliseconds
+	}
+
+	SSEDecoder struct {
+		reader *bufio.Reader
+		lastId string
+	}
+)
+
+// NDJSON, one json value per line
+func NewNDJSONDecoder(reader io.Reader) Decoder {
+	return json.NewDecoder(reader)
+}
+
+func NewSSEDecoder(reader io.Reader) *SSEDecoder {
+	return &SSEDecoder{reader: bufio.NewReader(reader)}
+}
+
+// Decode the next event into v: *Event gets the raw event, anything else is unmarshalled from the data as json
+func (dec *SSEDecoder) Decode(v interface{}) (err error) {
+	var event *Event
+	event, err = dec.Next()
+	if err == nil {
+		if raw, ok := v.(*Event); ok {
+			*raw = *event
+		} else if event.Data == Done {
+			err = io.EOF
+		} else {
+			err = json.Unmarshal([]byte(event.Data), v)
+		}
+	}
+	return
+}
+
+// Next event as defined in https://html.spec.whatwg.org/multipage/server-sent-events.html;
+// an incomplete event at the end of stream is discarded
+func (dec *SSEDecoder) Next() (event *Event, err error) {
+	data := new(strings.Builder)
+	event = new(Event)
+	for {
+		var line string
+		line, err = dec.readLine()
+		if err != nil {
+			break
+		}
+
+		if line == ZeroStr {
+			if data.Len() == 0 {
+				event = new(Event)
+				continue
+			}
+			event.Id = dec.lastId
+			event.Data = strings.TrimSuffix(data.String(), LF)
+			break
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ZeroStr
+		if colon := strings.Index(line, ":"); colon != -1 {
+			field = line[:colon]
+			value = strings.TrimPrefix(line[colon+1:], " ")
+		}
+
+		switch field {
+		case fieldId:
+			if !strings.Contains(value, "\x00") {
+				dec.lastId = value
+			}
+		case fieldEvent:
+			event.Event = value
+		case fieldData:
+			data.WriteString(value)
+			data.WriteString(LF)
+		case fieldRetry:
+			if retry, parseErr := strconv.Atoi(value); parseErr == nil {
+				event.Retry = retry
+			}
+		}
+	}
+	if err != nil {
+		event = nil
+	}
+	return
+}
+
+// line terminated by CRLF, LF or CR
+func (dec *SSEDecoder) readLine() (line string, err error) {
+	builder := new(strings.Builder)
+	for {
+		var char byte
+		char, err = dec.reader.ReadByte()
+		if err != nil {
+			break
+		}
+		if char == '\n' {
+			break
+		}
+		if char == '\r' {
+			if next, peekErr := dec.reader.Peek(1); peekErr == nil && next[0] == '\n' {
+				dec.reader.ReadByte()
+			}
+			break
+		}
+		builder.WriteByte(char)
+	}
+	line = builder.String()
+	return
+}
@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+type Token struct {
+	Text string `json:"text"`
+}
+
+func TestSSEDecoder_Decode(t *testing.T) {
+	decoder := NewSSEDecoder(strings.NewReader(": ping\r\n" +
+		"id: 1\r\n" +
+		"event: token\r\n" +
+		"data: {\"text\":\n" +
+		"data: \"hello\"}\n" +
+		"\n" +
+		"data: {\"text\": \"world\"}\r\r" +
+		"data: [DONE]\n\n"))
+
+	event := new(Event)
+	assert.Nil(t, decoder.Decode(event))
+	assert.Equal(t, "1", event.Id)
+	assert.Equal(t, "token", event.Event)
+	assert.Equal(t, "{\"text\":\n\"hello\"}", event.Data)
+
+	token := new(Token)
+	assert.Nil(t, decoder.Decode(token))
+	assert.Equal(t, "world", token.Text)
+	assert.Equal(t, io.EOF, decoder.Decode(token))
+	assert.Equal(t, io.EOF, decoder.Decode(token))
+}
+
+func TestNDJSONDecoder_Decode(t *testing.T) {
+	decoder := NewNDJSONDecoder(strings.NewReader("{\"text\": \"hello\"}\n{\"text\": \"world\"}\n"))
+	token := new(Token)
+	assert.Nil(t, decoder.Decode(token))
+	assert.Equal(t, "hello", token.Text)
+	assert.Nil(t, decoder.Decode(token))
+	assert.Equal(t, "world", token.Text)
+	assert.Equal(t, io.EOF, decoder.Decode(token))
+}
@@ -1,26 +1,41 @@
 package test
 
 import (
+	"context"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
 	"time"
 )
 
-//go:generate go run ../main.go Service
+//go:generate go run .. -type Service
 
 /*
 @HttpService
  */
 type (
 	/*
-	@Base {scheme}://box.zjuqsc.com/item
+	@Base {scheme}://box.zjuqsc.com/item, {scheme}://box-backup.zjuqsc.com/item
 	@Header(User-Agent) {userAgent}
 	@Cookie(ga) {ga}
-	@Cookie(qsc_session) secure_7y7y1n570y
+	@Cookie(qsc_session, path=/item, secure, httponly) secure_7y7y1n570y
+	@Compress gzip
+	@Body json
+	@Result json
+	@CircuitBreaker(failures=5, open=30s)
+	@RateLimit 10/s burst=20 adaptive
+	@Timeout 10s
+	@Retry(attempts=2)
 	*/
 	Service interface {
+		SetBaseURL(rawURL string) error
+		Curl(request *http.Request) (string, error)
+
 		/*
 		@Get /get/{token}?page={page}&limit={limit}
+		@Cache(ttl=60s)
+		@Singleflight
 		 */
 		GetItem(token int, page int, limit int) (*http.Response, error)
 
@@ -35,9 +50,11 @@ type (
 
 		/*
 		@Put /change/{id}
-		@Body json
+		@CircuitBreaker(failures=3, open=1m)
 		@Cookie(ga) {cookie}
-		@Result json
+		@Timeout 5s
+		@Retry(attempts=3, backoff=100ms)
+		@Result json, xml
 		 */
 		UpdateItem(id int, cookie string, data *time.Time, apiKey string) (result *UploadResult, statusCode int, err error)
 
@@ -48,7 +65,7 @@ type (
 		StatItem(id int, body *StatBody) (*http.Response, error)
 
 		/*
-		@Post /stat/{id}
+		@Post /stat/{id}/reader
 		@SingleBody json
 		 */
 		StatByReader(id int, body io.Reader) (*http.Response, error)
@@ -59,6 +76,70 @@ type (
 		@Param(name) {firstName}.Lee
 		 */
 		PostInfo(id int, firstName string) (*http.Request, error)
+
+		/*
+		@Post /complete
+		@SingleBody json
+		@Result sse
+		 */
+		Complete(ctx context.Context, prompt *Prompt) (<-chan *Token, <-chan error, error)
+
+		/*
+		@Get /changes?since={since}
+		@RateLimit 1/500ms fail
+		@Result ndjson
+		 */
+		WatchChanges(ctx context.Context, since int) (<-chan Change, <-chan error, error)
+
+		/*
+		@Put /item/{id}
+		@Body json
+		@Compress zstd
+		 */
+		PutItem(ctx context.Context, req *PutItemRequest) (*http.Response, error)
+
+		/*
+		@Post /search
+		@Body form
+		 */
+		Search(query *SearchQuery, tags []string, extra map[string]string, since *time.Time) (*http.Response, error)
+
+		/*
+		@Post /profile
+		@Body multipart
+		 */
+		PostProfile(profile Profile, avatar io.Reader) (*http.Response, error)
+
+		/*
+		@Post /attachments
+		@Body multipart
+		@File(avatar, filename={name}.png, type=image/png) {avatar}
+		@File(video, filename=video.mp4, type=video/mp4) {video}
+		@File(log) {log}
+		@File(icon, fs={assets}) icons/{name}.png
+		 */
+		PostAttachments(name string, avatar []byte, video io.Reader, log *os.File, assets fs.FS) (*http.Response, error)
+
+		/*
+		@Get /items
+		@Headers {header}
+		@Headers {extraHeader}
+		@Cookies {cookies}
+		@Cookies {extraCookies}
+		 */
+		ListItems(header http.Header, extraHeader map[string]string, cookies []*http.Cookie, extraCookies map[string]string) (*http.Response, error)
+
+		/*
+		@Get /items/search
+		 */
+		FindItems(keyword string, tags []string, since *time.Time, paging *Paging) (*http.Response, error)
+
+		/*
+		@Get /reports/{day:date}/{id:%08d}?from={from:rfc3339}&window={window:seconds}&region={region}
+		@Header(If-Modified-Since) {from:http}
+		@Cookie(region) {region}
+		 */
+		GetReport(id int, day, from time.Time, window time.Duration, region Region) (*http.Response, error)
 	}
 )
 
@@ -67,3 +148,52 @@ type UploadResult struct {
 
 type StatBody struct {
 }
+
+type Prompt struct {
+}
+
+type Token struct {
+}
+
+type Change struct {
+}
+
+type Paging struct {
+	Page  int `query:"page,omitempty"`
+	Limit int `query:"limit,omitempty"`
+}
+
+type Trace struct {
+	TraceId string `header:"X-Trace"`
+}
+
+type PutItemRequest struct {
+	Paging
+	Id      int      `path:"id"`
+	Tags    []string `query:"tag"`
+	Ga      string   `cookie:"ga,omitempty"`
+	Name    string   `json:"name"`
+	Comment *string  `json:"comment,omitempty"`
+	Trace   *Trace
+	Count   int
+}
+
+type SearchQuery struct {
+	Keyword string   `form:"q"`
+	Fields  []string `form:"field"`
+	Limit   *int     `form:"limit"`
+	Offset  int      `form:"offset,omitempty"`
+	Secret  string   `form:"-"`
+}
+
+type Region string
+
+func (region Region) MarshalText() ([]byte, error) {
+	return []byte("region-" + region), nil
+}
+
+type Profile struct {
+	Name   string
+	Age    int64 `json:"age"`
+	Labels map[string][]string
+}